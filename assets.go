@@ -0,0 +1,12 @@
+// Package premodernonsdagar embeds the templates and static files, so the binary is served without them on disk.
+package premodernonsdagar
+
+import "embed"
+
+//go:embed templates/*.tmpl
+var Templates embed.FS
+
+// The card images cached in static/images/scryfall are downloaded while serving, and served by /images
+//
+//go:embed static/*.css static/favicon.ico static/admin static/images/*.jpg
+var Static embed.FS
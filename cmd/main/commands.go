@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/audit"
+	"premodernonsdagar/internal/auth"
+	"premodernonsdagar/internal/cardmatcher"
+	"premodernonsdagar/internal/config"
+	"premodernonsdagar/internal/decklists"
+	"premodernonsdagar/internal/images"
+	"premodernonsdagar/internal/series"
+	"premodernonsdagar/internal/store"
+	"premodernonsdagar/internal/utils"
+)
+
+// The ETags of the input files when last pulled or pushed by the inputs commands
+const inputsStatePath = ".inputs-sync.json"
+
+// cliUser is the user changes made from the command line are logged as in the audit log
+func cliUser() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user + " (cli)"
+	}
+	return "cli"
+}
+
+func findSeries(id string) (series.Series, error) {
+	allSeries, err := series.Load()
+	if err != nil {
+		return series.Series{}, fmt.Errorf("failed to load series: %w", err)
+	}
+	s, found := series.Find(allSeries, id)
+	if !found {
+		return series.Series{}, fmt.Errorf("there is no series %q", id)
+	}
+	return s, nil
+}
+
+func parseDate(date string) error {
+	if _, err := time.Parse(time.DateOnly, date); err != nil {
+		return usageError(fmt.Sprintf("Invalid date %q, expected YYYY-MM-DD", date))
+	}
+	return nil
+}
+
+// runValidate prints the validation issues of the given event files, or all input events if none are given
+func runValidate(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	paths, err := parseArgs(flags, args, 0, -1)
+	if err != nil {
+		return err
+	}
+
+	reports := make(map[string]aggregation.ValidationReport)
+	if len(paths) == 0 {
+		all, err := aggregation.ValidateEventFiles()
+		if err != nil {
+			return fmt.Errorf("failed to validate events: %w", err)
+		}
+		reports = all
+		for path := range all {
+			paths = append(paths, path)
+		}
+		slices.Sort(paths)
+	}
+
+	valid := true
+	for _, path := range paths {
+		report, exists := reports[path]
+		if !exists {
+			var err error
+			report, err = aggregation.ValidateEventFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to validate events: %w", err)
+			}
+		}
+
+		for _, issue := range report.Issues {
+			fmt.Printf("%s: %s\n", path, issue)
+		}
+		if report.HasErrors() {
+			valid = false
+		}
+	}
+
+	fmt.Printf("Validated %d event files\n", len(paths))
+	if !valid {
+		return errors.New("some event files have errors")
+	}
+	return nil
+}
+
+// runEventNew writes an event file with the players and empty matches, like the file the admin section creates
+func runEventNew(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	seriesID := flags.String("series", "", "`ID` of the series, the main series if empty")
+	name := flags.String("name", "", "`NAME` of the event (default \"Onsdagstävling DATE\")")
+	rounds := flags.Int("rounds", 0, "play `N` rounds (default the usual number for the series)")
+	matches := flags.Int("matches", 0, "add `N` empty matches to fill in")
+	args, err := parseArgs(flags, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	date, players := args[0], args[1:]
+	if err := parseDate(date); err != nil {
+		return err
+	}
+
+	s, err := findSeries(*seriesID)
+	if err != nil {
+		return err
+	}
+	existing, err := audit.CurrentEvent(s.InputDir(), date)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("there is already an event on %s", date)
+	}
+
+	event := aggregation.InputEvent{
+		Name:       *name,
+		Date:       date,
+		Rounds:     *rounds,
+		PlayerInfo: make(map[string]aggregation.PlayerEventInfo),
+		Matches:    make([]aggregation.Match, max(*matches, 0)),
+	}
+	if event.Name == "" {
+		event.Name = "Onsdagstävling " + date
+	}
+	if event.Rounds <= 0 {
+		event.Rounds = s.Rounds
+	}
+	for _, player := range players {
+		event.PlayerInfo[strings.Join(strings.Fields(player), " ")] = aggregation.PlayerEventInfo{}
+	}
+
+	if err := audit.SaveEvent(s.InputDir(), cliUser(), audit.ActionCreate, "", event); err != nil {
+		return err
+	}
+	fmt.Printf("Created %s\n", filepath.Join(s.InputDir(), "events", date+".json"))
+	return nil
+}
+
+// runPlayerRename renames a player in the player registry, so the event files keep the name they were entered with
+func runPlayerRename(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	args, err := parseArgs(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	player, name := args[0], args[1]
+
+	registry, err := aggregation.LoadPlayerRegistry()
+	if err != nil {
+		return err
+	}
+	directory, err := aggregation.PlayerDirectory(registry)
+	if err != nil {
+		return err
+	}
+
+	// The player can be given by their ID or any of their names
+	id := registry.ID(player)
+	for _, entry := range directory {
+		if entry.ID == player {
+			id = entry.ID
+		}
+	}
+	id, err = registry.RegisterFromDirectory(directory, id)
+	if errors.Is(err, aggregation.ErrPlayerNotFound) {
+		return fmt.Errorf("there is no player %q", player)
+	}
+	if err != nil {
+		return err
+	}
+	if err := registry.Rename(id, name); err != nil {
+		return err
+	}
+	if err := registry.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Renamed %s to %s, the old name is kept as an alias\n", player, name)
+	return nil
+}
+
+// runDecklistAdd saves a decklist the way the admin section does, from a file or pasted into stdin
+func runDecklistAdd(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	seriesID := flags.String("series", "", "`ID` of the series, the main series if empty")
+	deck := flags.String("deck", "", "`NAME` of the deck, kept as it is if empty")
+	args, err := parseArgs(flags, args, 2, 3)
+	if err != nil {
+		return err
+	}
+	date, player := args[0], args[1]
+	if err := parseDate(date); err != nil {
+		return err
+	}
+
+	s, err := findSeries(*seriesID)
+	if err != nil {
+		return err
+	}
+	event, err := audit.CurrentEvent(s.InputDir(), date)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return fmt.Errorf("there is no event on %s", date)
+	}
+	// The player can be given by name or by the slug in the URL of the admin pages
+	name, found := decklists.FindPlayer(event, player)
+	if !found {
+		name, found = decklists.FindPlayer(event, utils.Slugify(player))
+	}
+	if !found {
+		return fmt.Errorf("%s did not play in the event on %s", player, date)
+	}
+
+	var content []byte
+	if len(args) == 3 {
+		content, err = os.ReadFile(args[2])
+	} else {
+		if info, statErr := os.Stdin.Stat(); statErr == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprintln(os.Stderr, "Paste the decklist and end it with Ctrl-D:")
+		}
+		content, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read decklist: %w", err)
+	}
+
+	if err := decklists.Save(s, event, name, string(content), *deck, cliUser()); err != nil {
+		return err
+	}
+	fmt.Printf("Saved the decklist of %s in the event on %s\n", name, date)
+	return nil
+}
+
+// runCardDBBuild builds the card database from Scryfall's bulk data, downloading it unless a file is given
+func runCardDBBuild(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	bulkFile := flags.String("bulk", "", "Scryfall default_cards bulk data `FILE` to use instead of downloading it")
+	out := flags.String("out", "files/db.json", "card database `FILE` to write")
+	if _, err := parseArgs(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	var bulkData io.ReadCloser
+	if *bulkFile != "" {
+		file, err := os.Open(*bulkFile)
+		if err != nil {
+			return fmt.Errorf("failed to open bulk data: %w", err)
+		}
+		bulkData = file
+	} else {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		fmt.Fprintln(os.Stderr, "Downloading the bulk data from Scryfall...")
+		body, err := cardmatcher.DownloadBulkData(ctx, http.DefaultClient)
+		if err != nil {
+			return err
+		}
+		bulkData = body
+	}
+	defer bulkData.Close()
+
+	cards, err := cardmatcher.BuildDatabase(bufio.NewReader(bulkData))
+	if err != nil {
+		return err
+	}
+
+	previous := cardmatcher.NewCardDatabase()
+	previous.LoadDatabase(*out)
+	if err := cardmatcher.SaveDatabase(*out, cards); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d cards to %s, it had %d cards before\n", len(cards), *out, previous.GetCardCount())
+	return nil
+}
+
+// runPrefetchImages fills the card image cache with every card in the card databases of the series
+func runPrefetchImages(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	if _, err := parseArgs(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	allSeries, err := series.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load series: %w", err)
+	}
+
+	urls := []string{}
+	loaded := map[string]bool{}
+	for _, s := range allSeries {
+		if loaded[s.CardDatabase] {
+			continue
+		}
+		loaded[s.CardDatabase] = true
+
+		db := cardmatcher.NewCardDatabase()
+		if err := db.LoadDatabase(s.CardDatabase); err != nil {
+			return err
+		}
+		urls = append(urls, db.ImageURLs()...)
+	}
+
+	cache, err := images.New(cfg.ImageCacheDir, cfg.ImageCacheSize)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fetched, err := cache.Prefetch(ctx, urls, 4)
+	fmt.Printf("Fetched %d of %d card images\n", fetched, len(urls))
+	return err
+}
+
+// runHashPassword reads a password from stdin and prints the hash to use in ADMIN_USERS
+func runHashPassword(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	if _, err := parseArgs(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		return fmt.Errorf("password cannot be empty")
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hash)
+	return nil
+}
+
+func runInputsPull(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	return syncInputsOnce(cfg, flags, args, (*store.Mirror).Pull, "Downloaded")
+}
+
+func runInputsPush(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	return syncInputsOnce(cfg, flags, args, (*store.Mirror).Push, "Uploaded")
+}
+
+// syncInputsOnce pulls or pushes the changed input files, remembering what was synced so a file edited on one
+// side is not overwritten by the older copy on the other side the next time
+func syncInputsOnce(cfg config.Config, flags *flag.FlagSet, args []string, sync func(*store.Mirror, context.Context) ([]string, error), done string) error {
+	if _, err := parseArgs(flags, args, 0, 0); err != nil {
+		return err
+	}
+	inputs := inputMirror(cfg)
+	if inputs == nil {
+		return errors.New("INPUT_S3_BUCKET is not set")
+	}
+	if err := inputs.LoadState(inputsStatePath); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	copied, syncErr := sync(inputs, ctx)
+	for _, key := range copied {
+		fmt.Println(key)
+	}
+	fmt.Fprintf(os.Stderr, "%s %d files\n", done, len(copied))
+
+	if err := inputs.SaveState(inputsStatePath); err != nil {
+		return err
+	}
+	return syncErr
+}
@@ -1,52 +1,179 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
-	"net/http"
+	"log/slog"
 	"os"
-	"slices"
+	"path/filepath"
+	"strings"
 
-	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/assets"
 	"premodernonsdagar/internal/config"
-	"premodernonsdagar/internal/handlers"
+	"premodernonsdagar/internal/requestid"
 	"premodernonsdagar/internal/templates"
 )
 
-func main() {
-	config := config.GetConfig()
+// command is a subcommand of the CLI, named by one or two words like "build" or "event new"
+type command struct {
+	name string
+	args string // The flags and arguments, for the help text
+	help string
+	run  func(cfg config.Config, flags *flag.FlagSet, args []string) error
+}
 
-	buildFlag := false
-	if len(os.Args) > 1 {
-		if slices.Contains(os.Args[1:], "--build") {
-			buildFlag = true
-		}
+var commands = []command{
+	{name: "serve", help: "Serve the site, aggregating the stats first if the input files changed (the default)", run: runServe},
+	{name: "build", help: "Aggregate the stats and render the pages without serving them", run: runBuild},
+	{name: "validate", args: "[FILE...]", help: "Check event files for errors, all of them if none are given", run: runValidate},
+	{name: "event new", args: "[-series ID] [-name NAME] [-rounds N] [-matches N] DATE [PLAYER...]", help: "Create an event file to fill in", run: runEventNew},
+	{name: "player rename", args: "PLAYER NAME", help: "Change the name a player is shown with, keeping the old name as an alias", run: runPlayerRename},
+	{name: "decklist add", args: "[-series ID] [-deck NAME] DATE PLAYER [FILE]", help: "Save the decklist of a player in an event, read from FILE or stdin", run: runDecklistAdd},
+	{name: "carddb build", args: "[-bulk FILE] [-out FILE]", help: "Build the card database from Scryfall's bulk data", run: runCardDBBuild},
+	{name: "prefetch-images", help: "Fetch the image of every card in the card databases into the image cache", run: runPrefetchImages},
+	{name: "hash-password", help: "Read a password from stdin and print the hash to use in ADMIN_USERS", run: runHashPassword},
+	{name: "inputs pull", help: "Download the input files changed in the bucket", run: runInputsPull},
+	{name: "inputs push", help: "Upload the input files changed locally to the bucket", run: runInputsPush},
+}
+
+// errBadFlags is returned after the flag package has printed what was wrong with the flags
+var errBadFlags = errors.New("bad flags")
+
+// usageError is returned for wrong arguments, and prints the help text of the command
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// findCommand returns the command named by the first words of args, and the arguments after the name
+func findCommand(args []string) (command, []string, bool) {
+	if len(args) == 0 {
+		return commands[0], nil, true
 	}
 
-	if config.DevelopmentEnvironment || buildFlag {
-		if err := aggregation.AggregateStats(); err != nil {
-			log.Fatalf("Error aggregating player stats: %v", err)
-		}
-		log.Println("Stats aggregated successfully.")
+	// The flags used before there were subcommands
+	switch args[0] {
+	case "--build":
+		args = append([]string{"build"}, args[1:]...)
+	case "--hash-password":
+		args = append([]string{"hash-password"}, args[1:]...)
+	}
 
-		err := templates.RenderAllTemplates()
-		if err != nil {
-			log.Fatalf("Error rendering templates: %v", err)
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
 		}
-		log.Println("All templates rendered successfully.")
 	}
+	return command{}, nil, false
+}
 
-	if buildFlag {
-		return
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [COMMAND] [ARGS]\n\nCommands:\n", programName())
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.help)
+	}
+	fmt.Fprintf(w, "\nRun %q for the arguments of a command. Settings are read from the environment, see the README.\n",
+		programName()+" COMMAND -h")
+}
+
+func printCommandUsage(w io.Writer, cmd command, flags *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: %s %s %s\n\n%s\n", programName(), cmd.name, cmd.args, cmd.help)
+	hasFlags := false
+	flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(w, "\nFlags:")
+		flags.PrintDefaults()
 	}
+}
 
-	// Start the web server
-	mux := handlers.SetupRoutes(config)
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
 
-	// Start the server
-	serverAddr := ":8080"
-	log.Println("Server started at http://localhost:8080")
-	err := http.ListenAndServe(serverAddr, mux)
+func main() {
+	config, err := config.GetConfig()
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("Error reading config: %v", err)
+	}
+
+	if len(os.Args) > 1 && (os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help") {
+		printUsage(os.Stdout)
+		return
+	}
+	cmd, args, found := findCommand(os.Args[1:])
+	if !found {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", strings.Join(os.Args[1:], " "))
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	setupLogging(config, cmd.name == "serve")
+
+	// Templates and static files are read from the source checkout while developing, before moving to the data
+	if config.DevelopmentEnvironment {
+		templateDir, _ := filepath.Abs("templates")
+		staticDir, _ := filepath.Abs("static")
+		templates.UseDisk(templateDir)
+		assets.UseDisk(staticDir)
+	}
+	if config.DataDir != "" {
+		if err := os.Chdir(config.DataDir); err != nil {
+			log.Fatalf("Error changing to the data directory: %v", err)
+		}
+	}
+
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.Usage = func() { printCommandUsage(flags.Output(), cmd, flags) }
+	err = cmd.run(config, flags, args)
+
+	var usage usageError
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errBadFlags):
+		os.Exit(2)
+	case errors.As(err, &usage):
+		fmt.Fprintf(os.Stderr, "%v\n\n", usage)
+		flags.Usage()
+		os.Exit(2)
+	case cmd.name == "serve":
+		slog.Error("Server failed", "err", err)
+		os.Exit(1)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseArgs parses the flags of a command, which come before its arguments, and checks the number of arguments.
+// A max of -1 allows any number.
+func parseArgs(flags *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errBadFlags
+	}
+	rest := flags.Args()
+	if len(rest) < min {
+		return nil, usageError("Missing arguments")
+	}
+	if max >= 0 && len(rest) > max {
+		return nil, usageError(fmt.Sprintf("Unexpected arguments: %s", strings.Join(rest[max:], " ")))
+	}
+	return rest, nil
+}
+
+// setupLogging logs JSON lines when serving in production and readable text while developing or running the other
+// commands, with the ID of the request the line was logged for
+func setupLogging(cfg config.Config, serving bool) {
+	var handler slog.Handler = slog.NewJSONHandler(os.Stderr, nil)
+	if cfg.DevelopmentEnvironment || !serving {
+		handler = slog.NewTextHandler(os.Stderr, nil)
 	}
+	slog.SetDefault(slog.New(requestid.LogHandler{Handler: handler}))
 }
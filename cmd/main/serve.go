@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/config"
+	"premodernonsdagar/internal/database"
+	"premodernonsdagar/internal/handlers"
+	"premodernonsdagar/internal/series"
+	"premodernonsdagar/internal/store"
+	"premodernonsdagar/internal/templates"
+)
+
+// runServe aggregates the stats if needed and serves the site until it is asked to stop
+func runServe(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	if _, err := parseArgs(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	inputs, inputsChanged, err := pullInputs(cfg)
+	if err != nil {
+		return err
+	}
+	if err := loadTemplates(cfg); err != nil {
+		return err
+	}
+
+	// A failed build leaves the previous stats in place, which are better than no site at all
+	rebuild := cfg.DevelopmentEnvironment || inputsChanged
+	if rebuild {
+		if err := buildStats(cfg); err != nil {
+			slog.Error("Error building stats, serving the previous build", "err", err)
+			rebuild = false
+		}
+	}
+	if !rebuild {
+		if err := handlers.ServeCurrentBuild(); err != nil {
+			return fmt.Errorf("failed to load the rebuilt stats: %w", err)
+		}
+	}
+
+	if cfg.DatabasePath != "" {
+		db, err := database.Open(cfg.DatabasePath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+		handlers.UseDatabase(db)
+	}
+
+	// Docker asks the service to stop with SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if inputs != nil {
+		handlers.UseInputMirror(inputs)
+		if cfg.InputSyncInterval > 0 {
+			go syncInputs(ctx, inputs, cfg)
+		}
+	}
+
+	serveErr := serve(ctx, cfg, handlers.SetupRoutes(cfg))
+	if inputs != nil {
+		if err := inputs.SaveState(inputsStatePath); err != nil {
+			slog.Error("Error saving input sync state", "err", err)
+		}
+	}
+	return serveErr
+}
+
+// runBuild aggregates the stats and renders the pages, which the Docker image does while it is built
+func runBuild(cfg config.Config, flags *flag.FlagSet, args []string) error {
+	if _, err := parseArgs(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	if _, _, err := pullInputs(cfg); err != nil {
+		return err
+	}
+	if err := loadTemplates(cfg); err != nil {
+		return err
+	}
+	return buildStats(cfg)
+}
+
+// pullInputs downloads the input files changed in the bucket, if the input files are kept in one.
+// The sync state is kept between runs, so files saved locally but not yet uploaded are not taken for stale copies.
+func pullInputs(cfg config.Config) (*store.Mirror, bool, error) {
+	inputs := inputMirror(cfg)
+	if inputs == nil {
+		return nil, false, nil
+	}
+	if err := inputs.LoadState(inputsStatePath); err != nil {
+		return nil, false, err
+	}
+
+	pulled, err := inputs.Pull(context.Background())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to download input files: %w", err)
+	}
+	if err := inputs.SaveState(inputsStatePath); err != nil {
+		return nil, false, err
+	}
+	slog.Info("Downloaded changed input files", "files", len(pulled), "bucket", cfg.InputBucket)
+	return inputs, len(pulled) > 0, nil
+}
+
+// inputMirror returns the mirror of the bucket the input files are kept in, or nil if there is none
+func inputMirror(cfg config.Config) *store.Mirror {
+	if cfg.InputBucket == "" {
+		return nil
+	}
+	remote := store.NewS3(cfg.InputEndpoint, cfg.InputRegion, cfg.InputBucket, cfg.InputPrefix, cfg.InputAccessKey, cfg.InputSecretKey)
+	return store.NewMirror(store.Local{Dir: "input"}, remote)
+}
+
+// loadTemplates parses the templates once from the binary, they are read from disk on every render while developing
+func loadTemplates(cfg config.Config) error {
+	if cfg.DevelopmentEnvironment {
+		return nil
+	}
+	if err := templates.Load(); err != nil {
+		return fmt.Errorf("failed to parse templates: %w", err)
+	}
+	return nil
+}
+
+func buildStats(cfg config.Config) error {
+	if err := aggregation.AggregateStats(cfg); err != nil {
+		return fmt.Errorf("failed to aggregate player stats: %w", err)
+	}
+	slog.Info("Stats aggregated successfully")
+
+	if err := storeDatabase(cfg); err != nil {
+		return err
+	}
+
+	if err := templates.RenderAllTemplates(); err != nil {
+		return fmt.Errorf("failed to render templates: %w", err)
+	}
+	slog.Info("All templates rendered successfully")
+
+	return handlers.DiscardBuilds()
+}
+
+// storeDatabase stores the aggregated stats in the database, if the pages are served from one
+func storeDatabase(cfg config.Config) error {
+	if cfg.DatabasePath == "" {
+		return nil
+	}
+	db, err := database.Open(cfg.DatabasePath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := aggregation.StoreDatabase(db, series.OutputRoot); err != nil {
+		return fmt.Errorf("failed to store the stats in the database: %w", err)
+	}
+	return nil
+}
+
+// serve runs the server until ctx is done, and then lets the open requests finish before returning
+func serve(ctx context.Context, cfg config.Config, handler http.Handler) error {
+	server := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	slog.Info("Server started", "addr", cfg.Addr)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for open requests", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	slog.Info("Server stopped")
+	return nil
+}
+
+// syncInputs downloads the input files changed in the bucket, and aggregates the stats again when any changed
+func syncInputs(ctx context.Context, inputs *store.Mirror, cfg config.Config) {
+	ticker := time.NewTicker(cfg.InputSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pulled, err := inputs.Pull(ctx)
+		if err != nil {
+			slog.Error("Error downloading input files", "err", err)
+			continue
+		}
+		if err := inputs.SaveState(inputsStatePath); err != nil {
+			slog.Error("Error saving input sync state", "err", err)
+		}
+		if len(pulled) == 0 {
+			continue
+		}
+
+		slog.Info("Downloaded changed input files, rebuilding stats", "files", len(pulled))
+		if err := handlers.Rebuild(cfg); err != nil {
+			slog.Error("Error rebuilding stats", "err", err)
+		}
+	}
+}
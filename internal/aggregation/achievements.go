@@ -0,0 +1,164 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// AchievementRule describes an achievement and when it is earned, rules are evaluated for every
+// player after each event they attended and awarded with the date of that event
+type AchievementRule struct {
+	ID          string
+	Title       string
+	Description string
+	Icon        string
+	Earned      func(stats *PlayerStats) bool
+}
+
+var achievementRules = []AchievementRule{
+	{
+		ID:          "first-undefeated",
+		Title:       "Flawless",
+		Description: "Went undefeated at an event",
+		Icon:        "trophy",
+		Earned:      func(stats *PlayerStats) bool { return stats.UndefeatedEvents >= 1 },
+	},
+	attendanceRule(10, "Regular"),
+	attendanceRule(25, "Veteran"),
+	attendanceRule(50, "Institution"),
+	{
+		ID:          "giant-slayer",
+		Title:       "Giant Slayer",
+		Description: "Beat the player ranked #1 by Elo",
+		Icon:        "swords",
+		Earned:      func(stats *PlayerStats) bool { return stats.BeatTopRated },
+	},
+	{
+		ID:          "archetypes-5",
+		Title:       "Brewer",
+		Description: "Played 5 different decks",
+		Icon:        "style",
+		Earned:      func(stats *PlayerStats) bool { return len(stats.Decks) >= 5 },
+	},
+	{
+		ID:          "full-season",
+		Title:       "Perfect Attendance",
+		Description: "Attended every event of a season",
+		Icon:        "workspace_premium",
+		Earned:      func(stats *PlayerStats) bool { return stats.FullSeasons >= 1 },
+	},
+}
+
+func attendanceRule(events int, title string) AchievementRule {
+	return AchievementRule{
+		ID:          fmt.Sprintf("events-%d", events),
+		Title:       title,
+		Description: fmt.Sprintf("Attended %d events", events),
+		Icon:        "military_tech",
+		Earned:      func(stats *PlayerStats) bool { return stats.AttendedEvents >= events },
+	}
+}
+
+// awardAchievements adds any newly earned achievements to the player
+func awardAchievements(stats *PlayerStats, date string) {
+	for _, rule := range achievementRules {
+		if hasAchievement(stats.Achievements, rule.ID) || !rule.Earned(stats) {
+			continue
+		}
+		stats.Achievements = append(stats.Achievements, Achievement{
+			ID:          rule.ID,
+			Title:       rule.Title,
+			Description: rule.Description,
+			Icon:        rule.Icon,
+			Date:        date,
+		})
+	}
+}
+
+func hasAchievement(achievements []Achievement, id string) bool {
+	for _, achievement := range achievements {
+		if achievement.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// The matches a player needs before they can be the top rated player, the ratings say little before that
+const minTopRatedMatches = 10
+
+// topRatedPlayer returns the player with the highest Elo among those who have played enough matches, or an empty
+// string if the top spot is shared or nobody has played enough
+func topRatedPlayer(players map[string]*PlayerStats) string {
+	topName := ""
+	topRating := 0
+	shared := false
+	for name, stats := range players {
+		if stats.TotalMatchesPlayed < minTopRatedMatches {
+			continue
+		}
+		if stats.EloRating > topRating {
+			topName, topRating, shared = name, stats.EloRating, false
+		} else if stats.EloRating == topRating {
+			shared = true
+		}
+	}
+	if shared {
+		return ""
+	}
+	return topName
+}
+
+func normalizeDeckName(deck string) string {
+	return strings.ToLower(strings.Join(strings.Fields(deck), " "))
+}
+
+func (a *aggregator) generateAchievementsOverview(players map[string]*PlayerStats) error {
+	overview := make([]AchievementOverview, 0, len(achievementRules))
+	for _, rule := range achievementRules {
+		entry := AchievementOverview{
+			ID:          rule.ID,
+			Title:       rule.Title,
+			Description: rule.Description,
+			Icon:        rule.Icon,
+			Holders:     []AchievementHolder{},
+		}
+
+		for id, stats := range players {
+			for _, achievement := range stats.Achievements {
+				if achievement.ID != rule.ID {
+					continue
+				}
+				entry.Holders = append(entry.Holders, AchievementHolder{
+					Name: stats.Name,
+					Date: achievement.Date,
+					URL:  a.siteURL("/players/" + id),
+				})
+			}
+		}
+
+		// First to earn it comes first
+		sort.Slice(entry.Holders, func(i, j int) bool {
+			if entry.Holders[i].Date != entry.Holders[j].Date {
+				return entry.Holders[i].Date < entry.Holders[j].Date
+			}
+			return entry.Holders[i].Name < entry.Holders[j].Name
+		})
+
+		overview = append(overview, entry)
+	}
+
+	output, err := json.MarshalIndent(overview, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal achievements overview: %w", err)
+	}
+
+	if err := os.WriteFile(a.outputPath("lists", "achievements.json"), output, 0644); err != nil {
+		return fmt.Errorf("failed to write achievements overview: %w", err)
+	}
+
+	return nil
+}
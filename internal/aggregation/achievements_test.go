@@ -0,0 +1,59 @@
+package aggregation
+
+import (
+	"testing"
+)
+
+func TestAwardAchievements(t *testing.T) {
+	stats := &PlayerStats{
+		Name:             "Alice",
+		AttendedEvents:   10,
+		UndefeatedEvents: 1,
+		Decks:            map[string]bool{"goblins": true},
+	}
+
+	awardAchievements(stats, "2025-09-02")
+
+	if !hasAchievement(stats.Achievements, "first-undefeated") || !hasAchievement(stats.Achievements, "events-10") {
+		t.Fatalf("Expected undefeated and attendance achievements, got %+v", stats.Achievements)
+	}
+	if hasAchievement(stats.Achievements, "events-25") || hasAchievement(stats.Achievements, "archetypes-5") {
+		t.Errorf("Unexpected achievements %+v", stats.Achievements)
+	}
+
+	stats.UndefeatedEvents = 2
+	stats.FullSeasons = 1
+	awardAchievements(stats, "2025-09-16")
+
+	if len(stats.Achievements) != 3 {
+		t.Fatalf("Expected 3 achievements, got %+v", stats.Achievements)
+	}
+	if stats.Achievements[0].Date != "2025-09-02" {
+		t.Errorf("Expected the first award date to be kept, got %s", stats.Achievements[0].Date)
+	}
+	if stats.Achievements[2].ID != "full-season" || stats.Achievements[2].Date != "2025-09-16" {
+		t.Errorf("Unexpected achievement %+v", stats.Achievements[2])
+	}
+}
+
+func TestTopRatedPlayer(t *testing.T) {
+	players := map[string]*PlayerStats{
+		"Alice": {EloRating: 1550, TotalMatchesPlayed: 4},
+		"Bob":   {EloRating: 1520, TotalMatchesPlayed: 9},
+	}
+	if top := topRatedPlayer(players); top != "" {
+		t.Errorf("Expected no top rated player before anyone has played enough matches, got %q", top)
+	}
+
+	players["Alice"].TotalMatchesPlayed = minTopRatedMatches
+	players["Bob"].TotalMatchesPlayed = minTopRatedMatches
+	players["Carl"] = &PlayerStats{EloRating: 1600, TotalMatchesPlayed: 3}
+	if top := topRatedPlayer(players); top != "Alice" {
+		t.Errorf("Expected Alice to be top rated, got %q", top)
+	}
+
+	players["Bob"].EloRating = 1550
+	if top := topRatedPlayer(players); top != "" {
+		t.Errorf("Expected no top rated player when shared, got %q", top)
+	}
+}
@@ -0,0 +1,162 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"premodernonsdagar/internal/database"
+	"premodernonsdagar/internal/series"
+)
+
+// The output directories stored as documents, the same files the handlers read when running without the database
+var databaseDocumentDirs = []string{"events", "players", "decklists", "lists"}
+
+// StoreDatabase stores the stats aggregated into root in the database, every series in one transaction, so the
+// database is only changed once the stats are complete
+func StoreDatabase(db *database.DB, root string) error {
+	allSeries, err := series.Load()
+	if err != nil {
+		return err
+	}
+
+	all := make(map[string]database.Series, len(allSeries))
+	for _, s := range allSeries {
+		a := &aggregator{series: s, root: root}
+		data, err := a.databaseSeries()
+		if err != nil {
+			return fmt.Errorf("failed to store series %s in the database: %w", s.ID, err)
+		}
+		all[s.ID] = data
+	}
+	return db.ReplaceAll(all)
+}
+
+// databaseSeries reads the aggregated files of the series for the database
+func (a *aggregator) databaseSeries() (database.Series, error) {
+	data := database.Series{}
+
+	for _, dir := range databaseDocumentDirs {
+		root := a.outputPath(dir)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || filepath.Ext(path) != ".json" {
+				return nil
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(a.outputPath(), path)
+			if err != nil {
+				return err
+			}
+			data.Documents = append(data.Documents, database.Document{Path: filepath.ToSlash(rel), Data: content})
+			return nil
+		})
+		if err != nil {
+			return data, fmt.Errorf("failed to read %s for the database: %w", dir, err)
+		}
+	}
+
+	for _, doc := range data.Documents {
+		var err error
+		switch dir, name := filepath.Split(doc.Path); dir {
+		case "events/":
+			err = addDatabaseEvent(&data, doc.Data)
+		case "players/":
+			err = addDatabasePlayer(&data, strings.TrimSuffix(name, ".json"), doc.Data)
+		case "decklists/":
+			err = addDatabaseDecklist(&data, strings.TrimSuffix(name, ".json"), doc.Data)
+		}
+		if err != nil {
+			return data, fmt.Errorf("failed to parse %s for the database: %w", doc.Path, err)
+		}
+	}
+
+	return data, nil
+}
+
+func addDatabaseEvent(data *database.Series, content []byte) error {
+	var event Event
+	if err := json.Unmarshal(content, &event); err != nil {
+		return err
+	}
+
+	row := database.Event{
+		Date:       event.Date,
+		Name:       event.Name,
+		Season:     event.Season,
+		Rounds:     event.Rounds,
+		Attendance: event.Attendance,
+	}
+	for _, result := range event.Results {
+		row.Results = append(row.Results, database.Result{Player: result.Name, Record: result.Result, Deck: result.Deck, Decklist: result.Decklist})
+	}
+	for _, match := range event.Matches {
+		row.Matches = append(row.Matches, database.Match{
+			Player1:           match.Player1,
+			Player2:           match.Player2,
+			Result:            match.Result,
+			Player1ExtraMatch: slices.Contains(match.ExtraMatch, match.Player1),
+			Player2ExtraMatch: slices.Contains(match.ExtraMatch, match.Player2),
+		})
+	}
+	data.Events = append(data.Events, row)
+	return nil
+}
+
+func addDatabasePlayer(data *database.Series, slug string, content []byte) error {
+	var player Player
+	if err := json.Unmarshal(content, &player); err != nil {
+		return err
+	}
+
+	row := database.Player{
+		Slug:             slug,
+		Name:             player.Name,
+		AttendedEvents:   player.AttendedEvents,
+		MatchesPlayed:    player.MatchesPlayed,
+		MatchWinRate:     player.MatchWinRate,
+		GameWinRate:      player.GameWinRate,
+		EloRating:        player.EloRating,
+		GlickoRating:     player.GlickoRating.Mu,
+		GlickoDeviation:  player.GlickoRating.Phi,
+		GlickoVolatility: player.GlickoRating.Sigma,
+	}
+	for _, entry := range player.EloHistory {
+		row.Ratings = append(row.Ratings, database.Rating{System: "elo", Date: entry.Date, Rating: entry.Score})
+	}
+	for _, entry := range player.GlickoHistory {
+		row.Ratings = append(row.Ratings, database.Rating{System: "glicko", Date: entry.Date, Rating: entry.Score})
+	}
+	data.Players = append(data.Players, row)
+	return nil
+}
+
+func addDatabaseDecklist(data *database.Series, id string, content []byte) error {
+	var decklist Decklist
+	if err := json.Unmarshal(content, &decklist); err != nil {
+		return err
+	}
+
+	row := database.Decklist{ID: id, Event: decklist.EventName, Player: decklist.PlayerName, Deck: decklist.DeckName}
+	for _, card := range decklist.MainDeck {
+		row.Cards = append(row.Cards, database.Card{Section: "main", Count: card.Count, Name: card.Name, CardType: card.CardType, Legality: card.Legality})
+	}
+	for _, card := range decklist.Sideboard {
+		row.Cards = append(row.Cards, database.Card{Section: "sideboard", Count: card.Count, Name: card.Name, CardType: card.CardType, Legality: card.Legality})
+	}
+	data.Decklists = append(data.Decklists, row)
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -13,56 +15,33 @@ import (
 	"strings"
 
 	"premodernonsdagar/internal/cardmatcher"
+	"premodernonsdagar/internal/metrics"
 )
 
-func processDecklistFile(cm *cardmatcher.CardMatcher, filePath string) (*Decklist, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	decklist := &Decklist{
-		MainDeck:  make([]DecklistCard, 0),
-		Sideboard: make([]DecklistCard, 0),
-	}
-
-	scanner := bufio.NewScanner(file)
-	inSideboard := false
-	lineNum := 0
+// Matches below this similarity are shown as uncertain when previewing a decklist
+const certainCardSimilarity = 0.9
 
-	// Extract the date from the base filename (first 10 characters)
-	baseName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
-	if len(baseName) < 10 {
-		return nil, fmt.Errorf("filename too short to extract date: %s", filePath)
-	}
-	date := baseName[:10]
-
-	// Open the event JSON file to get the event name
-	eventFilePath := filepath.Join("input/events", date+".json")
-	eventFile, err := os.Open(eventFilePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open event file: %w", err)
-	}
-	defer eventFile.Close()
+var cardMatches = metrics.NewCounter("premodern_card_matches_total",
+	"Decklist cards matched against the card database, by whether the match is certain, uncertain or missing.", "result")
 
-	var eventData InputEvent
-	decoder := json.NewDecoder(eventFile)
-	if err := decoder.Decode(&eventData); err != nil {
-		return nil, fmt.Errorf("failed to decode event file: %w", err)
-	}
+var cardLineRegex = regexp.MustCompile(`^(\d+)\s+(.+)$`)
 
-	decklist.EventName = eventData.Name
-	for playerName, playerInfo := range eventData.PlayerInfo {
-		if playerInfo.Decklist == baseName {
-			decklist.DeckName = playerInfo.Deck
-			decklist.PlayerName = playerName
-			break
-		}
+// ParseDecklist parses a plain text decklist, one "<count> <card name>" per line with the
+// sideboard after a line containing "sideboard", and matches the cards against the card database
+func ParseDecklist(cm *cardmatcher.CardMatcher, r io.Reader) (*ParsedDecklist, error) {
+	parsed := &ParsedDecklist{
+		Decklist: Decklist{
+			MainDeck:  make([]DecklistCard, 0),
+			Sideboard: make([]DecklistCard, 0),
+		},
+		Lines:      []DecklistLine{},
+		Unresolved: []UnresolvedDecklistLine{},
 	}
+	decklist := &parsed.Decklist
 
-	// Regex to match card lines: number followed by space(s) and card name
-	cardLineRegex := regexp.MustCompile(`^(\d+)\s+(.+)$`)
+	scanner := bufio.NewScanner(r)
+	inSideboard := false
+	lineNum := 0
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -82,7 +61,7 @@ func processDecklistFile(cm *cardmatcher.CardMatcher, filePath string) (*Decklis
 		// Parse card line
 		matches := cardLineRegex.FindStringSubmatch(line)
 		if len(matches) != 3 {
-			fmt.Printf("Warning: Skipping invalid line %d in %s: %s\n", lineNum, filePath, line)
+			parsed.Unresolved = append(parsed.Unresolved, UnresolvedDecklistLine{Line: lineNum, Text: line, Reason: "not formatted as <count> <card name>"})
 			continue
 		}
 
@@ -91,19 +70,29 @@ func processDecklistFile(cm *cardmatcher.CardMatcher, filePath string) (*Decklis
 
 		count, err := strconv.Atoi(countStr)
 		if err != nil {
-			fmt.Printf("Warning: Invalid count on line %d in %s: %s\n", lineNum, filePath, line)
+			parsed.Unresolved = append(parsed.Unresolved, UnresolvedDecklistLine{Line: lineNum, Text: line, Reason: "invalid count"})
 			continue
 		}
 
 		// Find the card using the card matcher
-		card, err := cm.FindCard(cardName)
+		similarity := 0.0
+		card := &cardmatcher.Card{
+			Name:     cardName,
+			ImageURL: "",
+			Legality: "unknown",
+		}
+		match, err := cm.FindCardWithInfo(cardName)
 		if err != nil {
-			fmt.Printf("Warning: Could not find card '%s' on line %d in %s: %v\n", cardName, lineNum, filePath, err)
 			// Still add the card with the original name
-			card = &cardmatcher.Card{
-				Name:     cardName,
-				ImageURL: "",
-				Legality: "unknown",
+			parsed.Unresolved = append(parsed.Unresolved, UnresolvedDecklistLine{Line: lineNum, Text: line, Reason: fmt.Sprintf("could not find card: %v", err)})
+			cardMatches.Inc("missing")
+		} else {
+			card = &match.Card
+			similarity = match.Similarity
+			if similarity < certainCardSimilarity {
+				cardMatches.Inc("uncertain")
+			} else {
+				cardMatches.Inc("certain")
 			}
 		}
 
@@ -115,6 +104,15 @@ func processDecklistFile(cm *cardmatcher.CardMatcher, filePath string) (*Decklis
 			CardType: card.CardType,
 		}
 
+		parsed.Lines = append(parsed.Lines, DecklistLine{
+			Line:       lineNum,
+			Text:       line,
+			Card:       decklistCard,
+			Sideboard:  inSideboard,
+			Similarity: similarity,
+			Uncertain:  similarity < certainCardSimilarity,
+		})
+
 		if inSideboard {
 			decklist.Sideboard = append(decklist.Sideboard, decklistCard)
 		} else {
@@ -123,7 +121,7 @@ func processDecklistFile(cm *cardmatcher.CardMatcher, filePath string) (*Decklis
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		return nil, fmt.Errorf("error reading decklist: %w", err)
 	}
 
 	for _, card := range decklist.MainDeck {
@@ -149,10 +147,59 @@ func processDecklistFile(cm *cardmatcher.CardMatcher, filePath string) (*Decklis
 		return decklist.Sideboard[i].Name < decklist.Sideboard[j].Name
 	})
 
+	return parsed, nil
+}
+
+func (a *aggregator) processDecklistFile(cm *cardmatcher.CardMatcher, filePath string) (*Decklist, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	// Extract the date from the base filename (first 10 characters)
+	baseName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	if len(baseName) < 10 {
+		return nil, fmt.Errorf("filename too short to extract date: %s", filePath)
+	}
+	date := baseName[:10]
+
+	// Open the event JSON file to get the event name
+	eventFilePath := a.inputPath("events", date+".json")
+	eventFile, err := os.Open(eventFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	defer eventFile.Close()
+
+	var eventData InputEvent
+	decoder := json.NewDecoder(eventFile)
+	if err := decoder.Decode(&eventData); err != nil {
+		return nil, fmt.Errorf("failed to decode event file: %w", err)
+	}
+
+	parsed, err := ParseDecklist(cm, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	for _, line := range parsed.Unresolved {
+		slog.Warn("Skipping decklist line", "path", filePath, "line", line.Line, "reason", line.Reason, "text", line.Text)
+	}
+
+	decklist := &parsed.Decklist
+	decklist.EventName = eventData.Name
+	for playerName, playerInfo := range eventData.PlayerInfo {
+		if playerInfo.Decklist == baseName {
+			decklist.DeckName = playerInfo.Deck
+			decklist.PlayerName = a.registry.Name(playerName)
+			break
+		}
+	}
+
 	return decklist, nil
 }
 
-func saveDecklistAsJSON(baseName string, decklist *Decklist) error {
+func (a *aggregator) saveDecklistAsJSON(baseName string, decklist *Decklist) error {
 	// Marshal to JSON with proper formatting
 	jsonData, err := json.MarshalIndent(decklist, "", "  ")
 	if err != nil {
@@ -160,7 +207,7 @@ func saveDecklistAsJSON(baseName string, decklist *Decklist) error {
 	}
 
 	// Write to file
-	outputFilePath := "files/decklists/" + baseName + ".json"
+	outputFilePath := a.outputPath("decklists", baseName+".json")
 	err = os.WriteFile(outputFilePath, jsonData, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write JSON file: %w", err)
@@ -169,8 +216,8 @@ func saveDecklistAsJSON(baseName string, decklist *Decklist) error {
 	return nil
 }
 
-func cleanupOldFiles(generatedFiles map[string]bool) error {
-	return filepath.WalkDir("files/decklists", func(path string, d fs.DirEntry, err error) error {
+func (a *aggregator) cleanupOldFiles(generatedFiles map[string]bool) error {
+	return filepath.WalkDir(a.outputPath("decklists"), func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -193,18 +240,18 @@ func cleanupOldFiles(generatedFiles map[string]bool) error {
 	})
 }
 
-func generateDecklists() error {
-	err := os.MkdirAll("files/decklists", 0755)
+func (a *aggregator) generateDecklists() error {
+	err := os.MkdirAll(a.outputPath("decklists"), 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create decklists directory: %w", err)
 	}
 
-	cm, err := cardmatcher.NewCardMatcher("files/db.json")
+	cm, err := cardmatcher.NewCardMatcher(a.series.CardDatabase)
 	if err != nil {
 		return fmt.Errorf("failed to initialize card matcher: %w", err)
 	}
 	// First, get list of files we'll create so we can clean up old ones
-	inputFiles, err := filepath.Glob("input/decklists/*.txt")
+	inputFiles, err := filepath.Glob(a.inputPath("decklists", "*.txt"))
 	if err != nil {
 		return fmt.Errorf("failed to list input files: %w", err)
 	}
@@ -218,18 +265,18 @@ func generateDecklists() error {
 
 		generatedFiles[baseName] = true
 
-		decklist, err := processDecklistFile(cm, inputFile)
+		decklist, err := a.processDecklistFile(cm, inputFile)
 		if err != nil {
 			return fmt.Errorf("failed to process %s: %w", inputFile, err)
 		}
 
-		err = saveDecklistAsJSON(baseName, decklist)
+		err = a.saveDecklistAsJSON(baseName, decklist)
 		if err != nil {
 			return fmt.Errorf("failed to save %s: %w", baseName, err)
 		}
 	}
 
-	err = cleanupOldFiles(generatedFiles)
+	err = a.cleanupOldFiles(generatedFiles)
 	if err != nil {
 		return fmt.Errorf("failed to cleanup old files: %w", err)
 	}
@@ -0,0 +1,58 @@
+package aggregation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"premodernonsdagar/internal/cardmatcher"
+)
+
+func TestParseDecklist(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db.json")
+	db := `[
+		{"name": "Swords to Plowshares", "image_url": "https://example.com/stp.jpg", "legality": "legal", "card_type": "other"},
+		{"name": "Plains", "image_url": "https://example.com/plains.jpg", "legality": "legal", "card_type": "land"},
+		{"name": "Savannah Lions", "image_url": "https://example.com/lions.jpg", "legality": "legal", "card_type": "creature"}
+	]`
+	if err := os.WriteFile(dbPath, []byte(db), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cm, err := cardmatcher.NewCardMatcher(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "4 Swords to Plowshares\n20 plains\n4 Savanah Lions\nDeck notes\n\nSideboard\n3 Swords to Plowshares\n"
+	parsed, err := ParseDecklist(cm, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseDecklist returned error: %v", err)
+	}
+
+	if parsed.Decklist.MainDeckCount != 28 || parsed.Decklist.SideboardCount != 3 {
+		t.Errorf("Expected 28 main deck and 3 sideboard cards, got %d and %d", parsed.Decklist.MainDeckCount, parsed.Decklist.SideboardCount)
+	}
+
+	order := []string{}
+	for _, card := range parsed.Decklist.MainDeck {
+		order = append(order, card.Name)
+	}
+	if strings.Join(order, ",") != "Savannah Lions,Swords to Plowshares,Plains" {
+		t.Errorf("Expected creatures, other and lands order, got %v", order)
+	}
+
+	if len(parsed.Unresolved) != 1 || parsed.Unresolved[0].Line != 4 {
+		t.Errorf("Expected line 4 to be unresolved, got %+v", parsed.Unresolved)
+	}
+
+	if len(parsed.Lines) != 4 {
+		t.Fatalf("Expected 4 parsed lines, got %d", len(parsed.Lines))
+	}
+	if parsed.Lines[0].Uncertain || parsed.Lines[1].Uncertain {
+		t.Error("Expected exact and case insensitive matches to be certain")
+	}
+	if !parsed.Lines[3].Sideboard {
+		t.Error("Expected the last line to be in the sideboard")
+	}
+}
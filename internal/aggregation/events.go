@@ -6,24 +6,22 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"premodernonsdagar/internal/utils"
-	"slices"
 	"sort"
 	"strings"
 )
 
-func generateEventsList() error {
-	err := os.MkdirAll("files/lists", 0755)
+func (a *aggregator) generateEventsList() error {
+	err := os.MkdirAll(a.outputPath("lists"), 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create lists directory: %w", err)
 	}
 
-	err = os.MkdirAll("files/events", 0755)
+	err = os.MkdirAll(a.outputPath("events"), 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create events directory: %w", err)
 	}
 
-	eventFiles, err := filepath.Glob("input/events/*.json")
+	eventFiles, err := filepath.Glob(a.inputPath("events", "*.json"))
 	if err != nil {
 		return fmt.Errorf("failed to read event files: %w", err)
 	}
@@ -58,7 +56,7 @@ func generateEventsList() error {
 
 	// Collect existing event JSON files
 	existingEventFiles := make(map[string]bool)
-	err = filepath.WalkDir("files/events", func(path string, d fs.DirEntry, err error) error {
+	err = filepath.WalkDir(a.outputPath("events"), func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -84,12 +82,34 @@ func generateEventsList() error {
 			return fmt.Errorf("failed to parse event file %s: %w", eventFile, err)
 		}
 
-		uniquePlayers := make(map[string]struct{})
-		for match := range eventData.Matches {
-			uniquePlayers[eventData.Matches[match].Player1] = struct{}{}
-			uniquePlayers[eventData.Matches[match].Player2] = struct{}{}
+		// Players are counted by their ID, so every spelling of a name is the same player, and the names are only
+		// resolved for the output
+		names := make(map[string]string)
+		for _, match := range eventData.Matches {
+			names[a.registry.ID(match.Player1)] = a.registry.Name(match.Player1)
+			names[a.registry.ID(match.Player2)] = a.registry.Name(match.Player2)
+		}
+		playerInfo := make(map[string]PlayerEventInfo)
+		for name, info := range eventData.PlayerInfo {
+			id := a.registry.ID(name)
+			// Prefer the entry that has a deck if the same player is listed under two names
+			if existing, exists := playerInfo[id]; exists && existing.Deck != "" {
+				continue
+			}
+			playerInfo[id] = info
+		}
+		attendance := len(names)
+
+		attendees := make([]string, 0, len(playerInfo))
+		for id := range playerInfo {
+			attendees = append(attendees, id)
+		}
+		for id := range names {
+			if _, listed := playerInfo[id]; !listed {
+				attendees = append(attendees, id)
+			}
 		}
-		attendance := len(uniquePlayers)
+		sort.Strings(attendees)
 
 		if attendance > eventsOutputData.MaxAttendance {
 			eventsOutputData.MaxAttendance = attendance
@@ -101,7 +121,7 @@ func generateEventsList() error {
 		attendances = append(attendances, attendance)
 
 		// Get season for this event
-		season, err := GetSeason(eventData.Date, firstEventDate)
+		season, err := GetSeason(eventData.Date, firstEventDate, a.series.SeasonMonths)
 		if err != nil {
 			return fmt.Errorf("failed to get season for event %s: %w", eventData.Date, err)
 		}
@@ -111,7 +131,7 @@ func generateEventsList() error {
 			Name:   eventData.Name + " (" + fmt.Sprintf("%d players", attendance) + ")",
 			Date:   eventData.Date,
 			Season: season,
-			URL:    "/events/" + eventData.Date,
+			URL:    a.siteURL("/events/" + eventData.Date),
 		}
 
 		eventsOutputData.Events = append(eventsOutputData.Events, event)
@@ -124,31 +144,37 @@ func generateEventsList() error {
 
 		for _, match := range eventData.Matches {
 			result := ParseMatchResult(match)
+			player1, player2 := a.registry.ID(match.Player1), a.registry.ID(match.Player2)
+			extra := make(map[string]bool)
+			for _, name := range match.ExtraMatch {
+				extra[a.registry.ID(name)] = true
+			}
 
 			if result.Draw {
-				if !slices.Contains(match.ExtraMatch, match.Player1) {
-					draws[match.Player1]++
-					points[match.Player1] += 1
+				if !extra[player1] {
+					draws[player1]++
+					points[player1] += 1
 				}
-				if !slices.Contains(match.ExtraMatch, match.Player2) {
-					draws[match.Player2]++
-					points[match.Player2] += 1
+				if !extra[player2] {
+					draws[player2]++
+					points[player2] += 1
 				}
 			} else {
-				if !slices.Contains(match.ExtraMatch, result.Winner) {
-					wins[result.Winner]++
-					points[result.Winner] += 3
+				winner, loser := a.registry.ID(result.Winner), a.registry.ID(result.Loser)
+				if !extra[winner] {
+					wins[winner]++
+					points[winner] += 3
 				}
-				if !slices.Contains(match.ExtraMatch, result.Loser) {
-					losses[result.Loser]++
-					points[result.Loser] += 0
+				if !extra[loser] {
+					losses[loser]++
+					points[loser] += 0
 				}
 			}
-			if !slices.Contains(match.ExtraMatch, match.Player1) {
-				matches[match.Player1]++
+			if !extra[player1] {
+				matches[player1]++
 			}
-			if !slices.Contains(match.ExtraMatch, match.Player2) {
-				matches[match.Player2]++
+			if !extra[player2] {
+				matches[player2]++
 			}
 		}
 
@@ -166,7 +192,7 @@ func generateEventsList() error {
 			if matches[keys[i]] != matches[keys[j]] {
 				return matches[keys[i]] > matches[keys[j]] // secondary: matches desc
 			}
-			return keys[i] < keys[j] // thirdly: name asc
+			return names[keys[i]] < names[keys[j]] // thirdly: name asc
 		})
 
 		for _, key := range keys {
@@ -175,14 +201,15 @@ func generateEventsList() error {
 				result += fmt.Sprintf("-%d", draws[key])
 			}
 			results = append(results, PlayerResult{
-				Name:     key,
+				Name:     names[key],
 				Result:   result,
-				Deck:     eventData.PlayerInfo[key].Deck,
-				Decklist: eventData.PlayerInfo[key].Decklist,
-				URL:      "/players/" + utils.Slugify(key),
+				Deck:     playerInfo[key].Deck,
+				Decklist: playerInfo[key].Decklist,
+				URL:      a.siteURL("/players/" + key),
 			})
 		}
 
+		a.registry.ResolveEvent(&eventData)
 		outputEvent := Event{
 			Name:       eventData.Name,
 			Date:       eventData.Date,
@@ -191,6 +218,7 @@ func generateEventsList() error {
 			Rounds:     eventData.Rounds,
 			Matches:    eventData.Matches,
 			Results:    results,
+			Attendees:  attendees,
 		}
 
 		// Save the updated event data back to its file
@@ -199,7 +227,7 @@ func generateEventsList() error {
 			return fmt.Errorf("failed to marshal updated event data for %s: %w", eventFile, err)
 		}
 
-		outputFilePath := "files/events/" + outputEvent.Date + ".json"
+		outputFilePath := a.outputPath("events", outputEvent.Date+".json")
 		if err := os.WriteFile(outputFilePath, updatedEventJSON, 0644); err != nil {
 			return fmt.Errorf("failed to write updated event data to %s: %w", eventFile, err)
 		}
@@ -236,7 +264,7 @@ func generateEventsList() error {
 		return fmt.Errorf("failed to marshal events list: %w", err)
 	}
 
-	if err := os.WriteFile("files/lists/events.json", eventsJSON, 0644); err != nil {
+	if err := os.WriteFile(a.outputPath("lists", "events.json"), eventsJSON, 0644); err != nil {
 		return fmt.Errorf("failed to write events.json: %w", err)
 	}
 
@@ -0,0 +1,277 @@
+package aggregation
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ExportsZip is the download with every export in it
+const ExportsZip = "stats.zip"
+
+// ExportFiles lists the CSV exports, in the order they are added to the zip
+var ExportFiles = []string{"matches.csv", "event_results.csv", "players.csv", "decklist_cards.csv"}
+
+// Excel only reads a CSV as UTF-8 when it starts with a byte order mark
+const utf8BOM = "\ufeff"
+
+// generateExports writes the aggregated events, players and decklists as CSV files for spreadsheets
+func (a *aggregator) generateExports() error {
+	err := os.MkdirAll(a.outputPath("exports"), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create exports directory: %w", err)
+	}
+
+	eventFiles, err := filepath.Glob(a.outputPath("events", "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list event files: %w", err)
+	}
+	sort.Strings(eventFiles)
+
+	events := make([]*Event, 0, len(eventFiles))
+	for _, file := range eventFiles {
+		event, err := readEventFile(file)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+
+	players, err := a.readExportPlayers()
+	if err != nil {
+		return err
+	}
+
+	decklistCards, err := a.decklistCardRows(events)
+	if err != nil {
+		return err
+	}
+
+	exports := map[string][][]string{
+		"matches.csv":        matchRows(events),
+		"event_results.csv":  eventResultRows(events),
+		"players.csv":        playerRows(players),
+		"decklist_cards.csv": decklistCards,
+	}
+
+	builtAt := time.Now()
+	var zipped bytes.Buffer
+	zipWriter := zip.NewWriter(&zipped)
+	for _, name := range ExportFiles {
+		content, err := encodeCSV(exports[name])
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+
+		if err := os.WriteFile(a.outputPath("exports", name), content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+
+		file, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: builtAt})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to the zip: %w", name, err)
+		}
+		if _, err := file.Write(content); err != nil {
+			return fmt.Errorf("failed to add %s to the zip: %w", name, err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to write the zip: %w", err)
+	}
+
+	if err := os.WriteFile(a.outputPath("exports", ExportsZip), zipped.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ExportsZip, err)
+	}
+
+	return nil
+}
+
+func encodeCSV(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(utf8BOM)
+
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (a *aggregator) readExportPlayers() ([]Player, error) {
+	files, err := filepath.Glob(a.outputPath("players", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list player files: %w", err)
+	}
+
+	players := make([]Player, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read player file %s: %w", file, err)
+		}
+
+		var player Player
+		if err := json.Unmarshal(data, &player); err != nil {
+			return nil, fmt.Errorf("failed to parse player file %s: %w", file, err)
+		}
+		players = append(players, player)
+	}
+
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].Name < players[j].Name
+	})
+	return players, nil
+}
+
+func matchRows(events []*Event) [][]string {
+	rows := [][]string{{"date", "season", "event", "player_1", "deck_1", "player_2", "deck_2", "result", "player_1_games", "player_2_games", "winner", "player_1_extra_match", "player_2_extra_match"}}
+
+	for _, event := range events {
+		decks := make(map[string]string, len(event.Results))
+		for _, result := range event.Results {
+			decks[result.Name] = result.Deck
+		}
+
+		for _, match := range event.Matches {
+			var games1, games2 string
+			var wins1, wins2 int
+			if _, err := fmt.Sscanf(match.Result, "%d-%d", &wins1, &wins2); err == nil {
+				games1, games2 = strconv.Itoa(wins1), strconv.Itoa(wins2)
+			}
+
+			winner := ParseMatchResult(match).Winner
+			if winner == "" {
+				winner = "draw"
+			}
+
+			rows = append(rows, []string{
+				event.Date,
+				event.Season,
+				event.Name,
+				match.Player1,
+				decks[match.Player1],
+				match.Player2,
+				decks[match.Player2],
+				match.Result,
+				games1,
+				games2,
+				winner,
+				strconv.FormatBool(slices.Contains(match.ExtraMatch, match.Player1)),
+				strconv.FormatBool(slices.Contains(match.ExtraMatch, match.Player2)),
+			})
+		}
+	}
+	return rows
+}
+
+func eventResultRows(events []*Event) [][]string {
+	rows := [][]string{{"date", "season", "event", "attendance", "position", "player", "record", "wins", "losses", "draws", "deck"}}
+
+	for _, event := range events {
+		for i, result := range event.Results {
+			var wins, losses, draws int
+			fmt.Sscanf(result.Result, "%d-%d-%d", &wins, &losses, &draws)
+
+			rows = append(rows, []string{
+				event.Date,
+				event.Season,
+				event.Name,
+				strconv.Itoa(event.Attendance),
+				strconv.Itoa(i + 1),
+				result.Name,
+				result.Result,
+				strconv.Itoa(wins),
+				strconv.Itoa(losses),
+				strconv.Itoa(draws),
+				result.Deck,
+			})
+		}
+	}
+	return rows
+}
+
+func playerRows(players []Player) [][]string {
+	rows := [][]string{{"player", "attended_events", "matches_played", "extra_matches_played", "draws", "undefeated_events", "unfinished_events", "match_win_rate", "game_win_rate", "elo_rating", "glicko_rating", "glicko_deviation", "glicko_volatility"}}
+
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	}
+
+	for _, player := range players {
+		rows = append(rows, []string{
+			player.Name,
+			strconv.Itoa(player.AttendedEvents),
+			strconv.Itoa(player.MatchesPlayed),
+			strconv.Itoa(player.ExtraMatchesPlayed),
+			strconv.Itoa(player.DrawCounter),
+			strconv.Itoa(player.UndefeatedEvents),
+			strconv.Itoa(player.UnfinishedEvents),
+			formatFloat(player.MatchWinRate),
+			formatFloat(player.GameWinRate),
+			strconv.Itoa(player.EloRating),
+			formatFloat(player.GlickoRating.Mu),
+			formatFloat(player.GlickoRating.Phi),
+			formatFloat(player.GlickoRating.Sigma),
+		})
+	}
+	return rows
+}
+
+// decklistCardRows lists every card of the decklists linked from the events, one row per card and section
+func (a *aggregator) decklistCardRows(events []*Event) ([][]string, error) {
+	rows := [][]string{{"date", "season", "event", "player", "deck", "section", "count", "card", "card_type", "legality"}}
+
+	for _, event := range events {
+		for _, result := range event.Results {
+			if result.Decklist == "" {
+				continue
+			}
+
+			data, err := os.ReadFile(a.outputPath("decklists", result.Decklist+".json"))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read decklist %s: %w", result.Decklist, err)
+			}
+
+			var decklist Decklist
+			if err := json.Unmarshal(data, &decklist); err != nil {
+				return nil, fmt.Errorf("failed to parse decklist %s: %w", result.Decklist, err)
+			}
+
+			sections := []struct {
+				Name  string
+				Cards []DecklistCard
+			}{
+				{"main", decklist.MainDeck},
+				{"sideboard", decklist.Sideboard},
+			}
+			for _, section := range sections {
+				for _, card := range section.Cards {
+					rows = append(rows, []string{
+						event.Date,
+						event.Season,
+						event.Name,
+						result.Name,
+						result.Deck,
+						section.Name,
+						strconv.Itoa(card.Count),
+						card.Name,
+						card.CardType,
+						card.Legality,
+					})
+				}
+			}
+		}
+	}
+	return rows, nil
+}
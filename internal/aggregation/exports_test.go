@@ -0,0 +1,65 @@
+package aggregation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchRows(t *testing.T) {
+	events := []*Event{{
+		Name:   "Onsdagstävling",
+		Date:   "2025-08-20",
+		Season: "2025-h2",
+		Matches: []Match{
+			{Player1: "Anna", Player2: "Erik", Result: "1-2"},
+			{Player1: "Anna", Player2: "Sara", Result: "1-1", ExtraMatch: []string{"Sara"}},
+		},
+		Results: []PlayerResult{{Name: "Anna", Deck: "Goblins"}, {Name: "Erik", Deck: "Stiflenought"}},
+	}}
+
+	rows := matchRows(events)
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %v", rows)
+	}
+
+	expected := []string{"2025-08-20", "2025-h2", "Onsdagstävling", "Anna", "Goblins", "Erik", "Stiflenought", "1-2", "1", "2", "Erik", "false", "false"}
+	if !reflect.DeepEqual(rows[1], expected) {
+		t.Errorf("Expected %v, got %v", expected, rows[1])
+	}
+	if rows[2][10] != "draw" || rows[2][11] != "false" || rows[2][12] != "true" {
+		t.Errorf("Expected a draw that is an extra match for player 2, got %v", rows[2])
+	}
+}
+
+func TestEventResultRows(t *testing.T) {
+	events := []*Event{{
+		Name:       "Onsdagstävling",
+		Date:       "2025-08-20",
+		Season:     "2025-h2",
+		Attendance: 2,
+		Results:    []PlayerResult{{Name: "Erik", Result: "2-0-1", Deck: "Stiflenought"}, {Name: "Anna", Result: "0-2"}},
+	}}
+
+	rows := eventResultRows(events)
+	expected := [][]string{
+		{"2025-08-20", "2025-h2", "Onsdagstävling", "2", "1", "Erik", "2-0-1", "2", "0", "1", "Stiflenought"},
+		{"2025-08-20", "2025-h2", "Onsdagstävling", "2", "2", "Anna", "0-2", "0", "2", "0", ""},
+	}
+	if !reflect.DeepEqual(rows[1:], expected) {
+		t.Errorf("Expected %v, got %v", expected, rows[1:])
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	content, err := encodeCSV([][]string{{"player", "deck"}, {"Anna", "Goblins, Sligh"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), utf8BOM) {
+		t.Error("Expected the CSV to start with a byte order mark")
+	}
+	if !strings.Contains(string(content), `Anna,"Goblins, Sligh"`) {
+		t.Errorf("Expected fields with commas to be quoted, got %q", content)
+	}
+}
@@ -3,22 +3,60 @@ package aggregation
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
-	"premodernonsdagar/internal/utils"
 	"sort"
 	"strings"
 )
 
-func generateLeaderboards() error {
+// PercentageLeaderboard is a leaderboard of a rate, and the rule players have to meet to be ranked on it
+type PercentageLeaderboard struct {
+	Title string
+	Score func(player Player) float64
+	Rule  EligibilityRule
+}
+
+// percentageBoards lists the percentage leaderboards. The raw rates need a few events, set per series, to keep players
+// with a single lucky event off the top, the adjusted rate already accounts for how few matches a player has played.
+func (a *aggregator) percentageBoards() []PercentageLeaderboard {
+	minimum := EligibilityRule{MinEvents: a.series.LeaderboardMinEvents}
+	return []PercentageLeaderboard{
+		{
+			Title: "Match Win Percentage",
+			Score: func(p Player) float64 { return p.MatchWinRate },
+			Rule:  minimum,
+		},
+		{
+			Title: "Adjusted Match Win Percentage",
+			Score: func(p Player) float64 { return wilsonLowerBound(p.MatchesWon, p.MatchesPlayed) },
+		},
+		{
+			Title: "Game Win Percentage",
+			Score: func(p Player) float64 { return p.GameWinRate },
+			Rule:  minimum,
+		},
+	}
+}
+
+func (a *aggregator) generateLeaderboards() error {
 	// Create leaderboards directory
-	leaderboardsDir := "files/lists/leaderboards"
+	leaderboardsDir := a.outputPath("lists", "leaderboards")
 	if err := os.MkdirAll(leaderboardsDir, 0755); err != nil {
 		return fmt.Errorf("failed to create leaderboards directory: %w", err)
 	}
 
+	if err := os.MkdirAll(a.outputPath("lists", "standings"), 0755); err != nil {
+		return fmt.Errorf("failed to create standings directory: %w", err)
+	}
+
+	pointsRules, err := a.loadSeasonPointsRules()
+	if err != nil {
+		return err
+	}
+
 	// Read all event files to get seasons
-	eventFiles, err := filepath.Glob("files/events/*.json")
+	eventFiles, err := filepath.Glob(a.outputPath("events", "*.json"))
 	if err != nil {
 		return fmt.Errorf("failed to read event files: %w", err)
 	}
@@ -43,19 +81,19 @@ func generateLeaderboards() error {
 	}
 
 	// Get current season
-	currentSeason, err := GetCurrentSeason(allEventDates)
+	currentSeason, err := GetCurrentSeason(allEventDates, a.series.SeasonMonths)
 	if err != nil {
 		return fmt.Errorf("failed to get current season: %w", err)
 	}
 
 	// Get all seasons
-	seasons, err := GetAllSeasons(allEventDates)
+	seasons, err := GetAllSeasons(allEventDates, a.series.SeasonMonths)
 	if err != nil {
 		return fmt.Errorf("failed to get all seasons: %w", err)
 	}
 
 	// Read all player files
-	playerDir := "files/players/"
+	playerDir := a.outputPath("players")
 	playerFiles, err := os.ReadDir(playerDir)
 	if err != nil {
 		return fmt.Errorf("failed to read player directory: %w", err)
@@ -78,17 +116,7 @@ func generateLeaderboards() error {
 		}
 	}
 
-	displaySeasons := make([]LeaderboardSeasonEntry, 0)
-	for _, season := range seasons {
-		url := "/leaderboards/" + season
-		if season == currentSeason {
-			url = "/leaderboards"
-		}
-		displaySeasons = append(displaySeasons, LeaderboardSeasonEntry{
-			Season: strings.ToUpper(season),
-			URL:    url,
-		})
-	}
+	displaySeasons := a.seasonEntries(seasons, currentSeason)
 
 	// Save seasons list
 
@@ -104,43 +132,42 @@ func generateLeaderboards() error {
 		// Calculate season-specific stats for each player
 		seasonPlayers := calculateSeasonStats(allPlayers, eventsInSeason)
 
+		standings, err := a.generateSeasonStandings(season, eventsInSeason, pointsRules)
+		if err != nil {
+			return err
+		}
+
+		seasonLeaderboards := []LeaderboardContainer{standings}
+		seasonLeaderboards = append(seasonLeaderboards, a.percentageLeaderboards(seasonPlayers)...)
+		seasonLeaderboards = append(seasonLeaderboards,
+			LeaderboardContainer{
+				Title:   "Played Events",
+				Entries: a.topN(seasonPlayers, func(p Player) float64 { return float64(p.AttendedEvents) }, 32),
+				Type:    "int",
+			},
+			LeaderboardContainer{
+				Title:   "Undefeated Events",
+				Entries: a.topN(seasonPlayers, func(p Player) float64 { return float64(p.UndefeatedEvents) }, 32),
+				Type:    "int",
+			},
+			LeaderboardContainer{
+				Title:   "Extra Matches Played",
+				Entries: a.topN(seasonPlayers, func(p Player) float64 { return float64(p.ExtraMatchesPlayed) }, 32),
+				Type:    "int",
+			},
+			LeaderboardContainer{
+				Title:   "Unfinished Events",
+				Entries: a.topN(seasonPlayers, func(p Player) float64 { return float64(p.UnfinishedEvents) }, 32),
+				Type:    "int",
+			},
+			a.upsetsLeaderboard(eventsInSeason),
+		)
+
 		leaderboards := LeaderbardsInformation{
-			Season:     strings.ToUpper(season),
-			AllSeasons: displaySeasons,
-			Leaderboards: []LeaderboardContainer{
-				{
-					Title:   "Match Win Percentage",
-					Entries: topN(seasonPlayers, func(p Player) float64 { return p.MatchWinRate }, 32),
-					Type:    "float",
-					Suffix:  "%",
-				},
-				{
-					Title:   "Game Win Percentage",
-					Entries: topN(seasonPlayers, func(p Player) float64 { return p.GameWinRate }, 32),
-					Type:    "float",
-					Suffix:  "%",
-				},
-				{
-					Title:   "Played Events",
-					Entries: topN(seasonPlayers, func(p Player) float64 { return float64(p.AttendedEvents) }, 32),
-					Type:    "int",
-				},
-				{
-					Title:   "Undefeated Events",
-					Entries: topN(seasonPlayers, func(p Player) float64 { return float64(p.UndefeatedEvents) }, 32),
-					Type:    "int",
-				},
-				{
-					Title:   "Extra Matches Played",
-					Entries: topN(seasonPlayers, func(p Player) float64 { return float64(p.ExtraMatchesPlayed) }, 32),
-					Type:    "int",
-				},
-				{
-					Title:   "Unfinished Events",
-					Entries: topN(seasonPlayers, func(p Player) float64 { return float64(p.UnfinishedEvents) }, 32),
-					Type:    "int",
-				},
-			}}
+			Season:       strings.ToUpper(season),
+			AllSeasons:   displaySeasons,
+			Leaderboards: seasonLeaderboards,
+		}
 
 		// Write season leaderboard file
 		output, err := json.MarshalIndent(leaderboards, "", "  ")
@@ -158,53 +185,54 @@ func generateLeaderboards() error {
 	currentSeasonEvents := eventsBySeason[currentSeason]
 	currentSeasonPlayers := calculateSeasonStats(allPlayers, currentSeasonEvents)
 
+	currentStandings, err := a.generateSeasonStandings(currentSeason, currentSeasonEvents, pointsRules)
+	if err != nil {
+		return err
+	}
+
+	currentSeasonLeaderboards := []LeaderboardContainer{
+		currentStandings,
+		{
+			Title:   "Elo Rating",
+			Entries: a.topN(allPlayers, func(p Player) float64 { return float64(p.EloRating) }, 32),
+			Type:    "int",
+		},
+		{
+			Title:   "Glicko2 Rating",
+			Entries: a.topN(allPlayers, func(p Player) float64 { return p.GlickoRating.Mu }, 32),
+			Type:    "float",
+		},
+	}
+	currentSeasonLeaderboards = append(currentSeasonLeaderboards, a.percentageLeaderboards(currentSeasonPlayers)...)
+	currentSeasonLeaderboards = append(currentSeasonLeaderboards,
+		LeaderboardContainer{
+			Title:   "Played Events",
+			Entries: a.topN(currentSeasonPlayers, func(p Player) float64 { return float64(p.AttendedEvents) }, 32),
+			Type:    "int",
+		},
+		LeaderboardContainer{
+			Title:   "Undefeated Events",
+			Entries: a.topN(currentSeasonPlayers, func(p Player) float64 { return float64(p.UndefeatedEvents) }, 32),
+			Type:    "int",
+		},
+		LeaderboardContainer{
+			Title:   "Extra Matches Played",
+			Entries: a.topN(currentSeasonPlayers, func(p Player) float64 { return float64(p.ExtraMatchesPlayed) }, 32),
+			Type:    "int",
+		},
+		LeaderboardContainer{
+			Title:   "Unfinished Events",
+			Entries: a.topN(currentSeasonPlayers, func(p Player) float64 { return float64(p.UnfinishedEvents) }, 32),
+			Type:    "int",
+		},
+		a.upsetsLeaderboard(currentSeasonEvents),
+	)
+
 	currentLeaderboards := LeaderbardsInformation{
-		Season:     strings.ToUpper(currentSeason),
-		AllSeasons: displaySeasons,
-		Leaderboards: []LeaderboardContainer{
-			{
-				Title:   "Elo Rating",
-				Entries: topN(allPlayers, func(p Player) float64 { return float64(p.EloRating) }, 32),
-				Type:    "int",
-			},
-			{
-				Title:   "Glicko2 Rating",
-				Entries: topN(allPlayers, func(p Player) float64 { return p.GlickoRating.Mu }, 32),
-				Type:    "float",
-			},
-			{
-				Title:   "Match Win Percentage",
-				Entries: topN(currentSeasonPlayers, func(p Player) float64 { return p.MatchWinRate }, 32),
-				Type:    "float",
-				Suffix:  "%",
-			},
-			{
-				Title:   "Game Win Percentage",
-				Entries: topN(currentSeasonPlayers, func(p Player) float64 { return p.GameWinRate }, 32),
-				Type:    "float",
-				Suffix:  "%",
-			},
-			{
-				Title:   "Played Events",
-				Entries: topN(currentSeasonPlayers, func(p Player) float64 { return float64(p.AttendedEvents) }, 32),
-				Type:    "int",
-			},
-			{
-				Title:   "Undefeated Events",
-				Entries: topN(currentSeasonPlayers, func(p Player) float64 { return float64(p.UndefeatedEvents) }, 32),
-				Type:    "int",
-			},
-			{
-				Title:   "Extra Matches Played",
-				Entries: topN(currentSeasonPlayers, func(p Player) float64 { return float64(p.ExtraMatchesPlayed) }, 32),
-				Type:    "int",
-			},
-			{
-				Title:   "Unfinished Events",
-				Entries: topN(currentSeasonPlayers, func(p Player) float64 { return float64(p.UnfinishedEvents) }, 32),
-				Type:    "int",
-			},
-		}}
+		Season:       strings.ToUpper(currentSeason),
+		AllSeasons:   displaySeasons,
+		Leaderboards: currentSeasonLeaderboards,
+	}
 
 	// Write current.json
 	currentOutput, err := json.MarshalIndent(currentLeaderboards, "", "  ")
@@ -241,7 +269,7 @@ func generateLeaderboards() error {
 		}
 	}
 
-	return nil
+	return a.cleanupSeasonStandings(seasons)
 }
 
 // calculateSeasonStats calculates player stats filtered by season events
@@ -411,6 +439,8 @@ func calculateSeasonStats(allPlayers []Player, eventsInSeason []Event) []Player
 			}
 		}
 
+		stats.MatchesPlayed = totalMatches
+		stats.MatchesWon = totalMatchWins
 		if totalMatches > 0 {
 			stats.MatchWinRate = float64(totalMatchWins) / float64(totalMatches) * 100
 		}
@@ -429,7 +459,119 @@ func calculateSeasonStats(allPlayers []Player, eventsInSeason []Event) []Player
 	return result
 }
 
-func topN(players []Player, scoreFunc func(Player) float64, n int) []LeaderboardEntry {
+func (rule EligibilityRule) isEligible(player Player) bool {
+	return player.AttendedEvents >= rule.MinEvents && player.MatchesPlayed >= rule.MinMatches
+}
+
+func (rule EligibilityRule) String() string {
+	requirements := []string{}
+	if rule.MinEvents > 0 {
+		requirements = append(requirements, fmt.Sprintf("%d events", rule.MinEvents))
+	}
+	if rule.MinMatches > 0 {
+		requirements = append(requirements, fmt.Sprintf("%d matches", rule.MinMatches))
+	}
+	if len(requirements) == 0 {
+		return ""
+	}
+	return "Minimum " + strings.Join(requirements, " and ")
+}
+
+func (rule EligibilityRule) missing(player Player) string {
+	missing := []string{}
+	if player.AttendedEvents < rule.MinEvents {
+		missing = append(missing, fmt.Sprintf("%d of %d events", player.AttendedEvents, rule.MinEvents))
+	}
+	if player.MatchesPlayed < rule.MinMatches {
+		missing = append(missing, fmt.Sprintf("%d of %d matches", player.MatchesPlayed, rule.MinMatches))
+	}
+	return strings.Join(missing, ", ")
+}
+
+// percentageLeaderboards creates the percentage leaderboards for the players
+func (a *aggregator) percentageLeaderboards(players []Player) []LeaderboardContainer {
+	leaderboards := []LeaderboardContainer{}
+	for _, board := range a.percentageBoards() {
+		leaderboards = append(leaderboards, a.rateLeaderboard(board, players))
+	}
+	return leaderboards
+}
+
+// rateLeaderboard creates a percentage leaderboard, listing players who do not meet its rule separately
+func (a *aggregator) rateLeaderboard(board PercentageLeaderboard, players []Player) LeaderboardContainer {
+	rule, scoreFunc := board.Rule, board.Score
+	eligible := []Player{}
+	ineligible := []Player{}
+	notes := make(map[string]string)
+	for _, player := range players {
+		if rule.isEligible(player) {
+			eligible = append(eligible, player)
+			continue
+		}
+		ineligible = append(ineligible, player)
+		notes[player.Name] = rule.missing(player)
+	}
+
+	ineligibleEntries := a.rankedEntries(ineligible, scoreFunc, len(ineligible))
+	for i := range ineligibleEntries {
+		ineligibleEntries[i].Note = notes[ineligibleEntries[i].Name]
+	}
+
+	return LeaderboardContainer{
+		Title:       board.Title,
+		Entries:     a.rankedEntries(eligible, scoreFunc, 32),
+		Type:        "float",
+		Suffix:      "%",
+		Requirement: rule.String(),
+		Ineligible:  ineligibleEntries,
+	}
+}
+
+// seasonEntries links every season to its leaderboards, the current season is shown without a season in the URL
+func (a *aggregator) seasonEntries(seasons []string, currentSeason string) []LeaderboardSeasonEntry {
+	entries := make([]LeaderboardSeasonEntry, 0, len(seasons))
+	for _, season := range seasons {
+		url := a.siteURL("/leaderboards/" + season)
+		if season == currentSeason {
+			url = a.siteURL("/leaderboards")
+		}
+		entries = append(entries, LeaderboardSeasonEntry{
+			Season: strings.ToUpper(season),
+			URL:    url,
+		})
+	}
+	return entries
+}
+
+// wilsonLowerBound is the lower bound of the 95% Wilson score interval for the match win rate, in percent
+func wilsonLowerBound(wins, matches int) float64 {
+	if matches == 0 {
+		return 0
+	}
+
+	z := 1.96
+	n := float64(matches)
+	p := float64(wins) / n
+
+	centre := p + z*z/(2*n)
+	margin := z * math.Sqrt((p*(1-p)+z*z/(4*n))/n)
+	lowerBound := (centre - margin) / (1 + z*z/n)
+
+	return math.Round(lowerBound*10000) / 100
+}
+
+func (a *aggregator) topN(players []Player, scoreFunc func(Player) float64, n int) []LeaderboardEntry {
+	scored := []Player{}
+	for _, player := range players {
+		if scoreFunc(player) > 0 {
+			scored = append(scored, player)
+		}
+	}
+	return a.rankedEntries(scored, scoreFunc, n)
+}
+
+// rankedEntries lists the n players with the highest scores, including players who scored zero
+func (a *aggregator) rankedEntries(players []Player, scoreFunc func(Player) float64, n int) []LeaderboardEntry {
 	sort.Slice(players, func(i, j int) bool {
 		scoreI, scoreJ := scoreFunc(players[i]), scoreFunc(players[j])
 		if scoreI == scoreJ {
@@ -439,15 +581,12 @@ func topN(players []Player, scoreFunc func(Player) float64, n int) []Leaderboard
 	})
 
 	var topPlayers []LeaderboardEntry
-	for i := 0; i < len(players) && len(topPlayers) < n; i++ {
-		score := scoreFunc(players[i])
-		if score > 0 {
-			topPlayers = append(topPlayers, LeaderboardEntry{
-				Name:  players[i].Name,
-				Score: score,
-				URL:   "/players/" + utils.Slugify(players[i].Name),
-			})
-		}
+	for _, player := range players[:min(len(players), n)] {
+		topPlayers = append(topPlayers, LeaderboardEntry{
+			Name:  player.Name,
+			Score: scoreFunc(player),
+			URL:   a.siteURL("/players/" + a.playerSlug(player.Name)),
+		})
 	}
 
 	return topPlayers
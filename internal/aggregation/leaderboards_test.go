@@ -0,0 +1,111 @@
+package aggregation
+
+import (
+	"testing"
+
+	"premodernonsdagar/internal/series"
+)
+
+func TestWilsonLowerBound(t *testing.T) {
+	tests := []struct {
+		name     string
+		wins     int
+		matches  int
+		expected float64
+	}{
+		{name: "no matches", wins: 0, matches: 0, expected: 0},
+		{name: "single event undefeated", wins: 4, matches: 4, expected: 51.01},
+		{name: "many matches", wins: 30, matches: 40, expected: 59.81},
+		{name: "rate that does not round evenly", wins: 2, matches: 3, expected: 20.77},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := wilsonLowerBound(tt.wins, tt.matches)
+			if result != tt.expected {
+				t.Errorf("wilsonLowerBound(%d, %d) = %v, want %v", tt.wins, tt.matches, result, tt.expected)
+			}
+		})
+	}
+
+	if wilsonLowerBound(4, 4) > wilsonLowerBound(30, 40) {
+		t.Error("Expected a long track record to rank above a single undefeated event")
+	}
+}
+
+func TestRateLeaderboard(t *testing.T) {
+	players := []Player{
+		{Name: "Alice", AttendedEvents: 1, MatchesPlayed: 4, MatchWinRate: 100},
+		{Name: "Bob", AttendedEvents: 5, MatchesPlayed: 20, MatchWinRate: 60},
+		{Name: "Carl", AttendedEvents: 3, MatchesPlayed: 12, MatchWinRate: 50},
+		{Name: "Dave", AttendedEvents: 4, MatchesPlayed: 12, MatchWinRate: 0},
+	}
+
+	a := testAggregator()
+	board := PercentageLeaderboard{
+		Title: "Match Win Percentage",
+		Score: func(p Player) float64 { return p.MatchWinRate },
+		Rule:  EligibilityRule{MinEvents: 3},
+	}
+	leaderboard := a.rateLeaderboard(board, players)
+
+	if leaderboard.Requirement != "Minimum 3 events" {
+		t.Errorf("Unexpected requirement %q", leaderboard.Requirement)
+	}
+
+	if len(leaderboard.Entries) != 3 || leaderboard.Entries[0].Name != "Bob" || leaderboard.Entries[1].Name != "Carl" || leaderboard.Entries[2].Name != "Dave" {
+		t.Errorf("Unexpected eligible entries: %+v", leaderboard.Entries)
+	}
+
+	if len(leaderboard.Ineligible) != 1 || leaderboard.Ineligible[0].Name != "Alice" {
+		t.Fatalf("Unexpected ineligible entries: %+v", leaderboard.Ineligible)
+	}
+
+	if leaderboard.Ineligible[0].Note != "1 of 3 events" {
+		t.Errorf("Unexpected ineligible note %q", leaderboard.Ineligible[0].Note)
+	}
+}
+
+func TestPercentageBoardsUseSeriesMinimum(t *testing.T) {
+	s := series.Default()
+	s.LeaderboardMinEvents = 5
+	a := &aggregator{series: s, root: series.OutputRoot, registry: NewPlayerRegistry(nil)}
+
+	players := []Player{{Name: "Bob", AttendedEvents: 4, MatchesPlayed: 16, MatchesWon: 10, MatchWinRate: 62.5, GameWinRate: 60}}
+	for _, leaderboard := range a.percentageLeaderboards(players) {
+		if leaderboard.Title == "Adjusted Match Win Percentage" {
+			if len(leaderboard.Entries) != 1 {
+				t.Errorf("Expected the adjusted rate to rank every player, got %+v", leaderboard.Entries)
+			}
+			continue
+		}
+		if leaderboard.Requirement != "Minimum 5 events" || len(leaderboard.Entries) != 0 || len(leaderboard.Ineligible) != 1 {
+			t.Errorf("Expected %s to need the series minimum of 5 events, got %+v", leaderboard.Title, leaderboard)
+		}
+	}
+}
+
+func TestSeasonEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		a      *aggregator
+		prefix string
+	}{
+		{"main series", testAggregator(), ""},
+		{"other series", &aggregator{series: series.Series{ID: "tisdag"}, root: series.OutputRoot, registry: NewPlayerRegistry(nil)}, "/series/tisdag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.a.seasonEntries([]string{"2025a", "2025b"}, "2025b")
+			if len(entries) != 2 {
+				t.Fatalf("Expected 2 seasons, got %+v", entries)
+			}
+			if entries[0].Season != "2025A" || entries[0].URL != tt.prefix+"/leaderboards/2025a" {
+				t.Errorf("Unexpected past season %+v", entries[0])
+			}
+			if entries[1].URL != tt.prefix+"/leaderboards" {
+				t.Errorf("Expected the current season at %s/leaderboards, got %s", tt.prefix, entries[1].URL)
+			}
+		})
+	}
+}
@@ -17,13 +17,15 @@ type PlayerResult struct {
 }
 
 type Event struct {
-	Name       string         `json:"name"`
-	Date       string         `json:"date"`
-	Season     string         `json:"season"`
-	Rounds     int            `json:"rounds"`
-	Matches    []Match        `json:"matches"`
-	Attendance int            `json:"attendance"`
-	Results    []PlayerResult `json:"results"`
+	Name       string            `json:"name"`
+	Date       string            `json:"date"`
+	Season     string            `json:"season"`
+	Rounds     int               `json:"rounds"`
+	Matches    []Match           `json:"matches"`
+	Attendance int               `json:"attendance"`
+	Results    []PlayerResult    `json:"results"`
+	Attendees  []string          `json:"attendees"` // The IDs of the players listed in the event, also those without matches
+	Prediction *PredictionReport `json:"prediction,omitempty"`
 }
 
 type PlayerEventInfo struct {
@@ -36,6 +38,11 @@ type Match struct {
 	Player2    string   `json:"player_2"`
 	Result     string   `json:"result"`
 	ExtraMatch []string `json:"extra_match,omitempty"`
+	Round      int      `json:"round,omitempty"` // 0 when the event does not say which round the match was in
+
+	// Pre-match chance for player 1 to win, set while replaying the ratings and nil for matches without a prediction
+	EloWinProbability    *float64 `json:"elo_win_probability,omitempty"`
+	GlickoWinProbability *float64 `json:"glicko_win_probability,omitempty"`
 }
 
 type MatchResult struct {
@@ -71,6 +78,10 @@ type PlayerStats struct {
 	EloHistory         []HistoryEntry
 	GlickoHistory      []HistoryEntry
 	WinRateHistory     []HistoryEntry
+	Decks              map[string]bool
+	BeatTopRated       bool
+	FullSeasons        int
+	Achievements       []Achievement
 }
 
 type GlickoOpponent struct {
@@ -124,12 +135,37 @@ type Player struct {
 	DrawCounter        int              `json:"draw_counter"`
 	GameWinRate        float64          `json:"game_win_rate"`
 	MatchWinRate       float64          `json:"match_win_rate"`
+	MatchesPlayed      int              `json:"matches_played"`
+	MatchesWon         int              `json:"matches_won"`
 	OpponentMatchups   []StatsContainer `json:"opponent_matchups"`
 	ExtraMatchesPlayed int              `json:"extra_matches_played"`
 	EloHistory         []HistoryEntry   `json:"elo_history"`
 	GlickoHistory      []HistoryEntry   `json:"glicko_history"`
 	WinRateHistory     []HistoryEntry   `json:"win_rate_history"`
 	MatchesWithDecks   []StatsContainer `json:"matches_with_deck"`
+	Achievements       []Achievement    `json:"achievements"`
+}
+
+type Achievement struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	Date        string `json:"date"`
+}
+
+type AchievementOverview struct {
+	ID          string              `json:"id"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Icon        string              `json:"icon"`
+	Holders     []AchievementHolder `json:"holders"`
+}
+
+type AchievementHolder struct {
+	Name string `json:"name"`
+	Date string `json:"date"`
+	URL  string `json:"url"`
 }
 
 type GlickoRating struct {
@@ -152,14 +188,55 @@ type LeaderbardsInformation struct {
 type LeaderboardContainer struct {
 	Title   string             `json:"title"`
 	Entries []LeaderboardEntry `json:"entries"`
-	Type    string             `json:"type,omitempty"` // "int" or "float", optional
+	Type    string             `json:"type,omitempty"` // "int", "float" or "standings", optional
 	Suffix  string             `json:"suffix,omitempty"`
+	CutLine int                `json:"cut_line,omitempty"` // Only used by "standings"
+	URL     string             `json:"url,omitempty"`
+
+	Requirement string             `json:"requirement,omitempty"`
+	Ineligible  []LeaderboardEntry `json:"ineligible,omitempty"`
 }
 
 type LeaderboardEntry struct {
-	Name  string      `json:"name"`
-	Score interface{} `json:"score"` // Can be float64 or int depending on the leaderboard
-	URL   string      `json:"url"`
+	Name      string      `json:"name"`
+	Score     interface{} `json:"score"` // Can be float64 or int depending on the leaderboard
+	URL       string      `json:"url"`
+	Qualified bool        `json:"qualified,omitempty"`
+	Note      string      `json:"note,omitempty"`
+}
+
+type EligibilityRule struct {
+	MinEvents  int
+	MinMatches int
+}
+
+type SeasonPointsRules struct {
+	ParticipationPoints int `json:"participation_points"`
+	MatchWinPoints      int `json:"match_win_points"`
+	MatchDrawPoints     int `json:"match_draw_points"`
+	CountedEvents       int `json:"counted_events"` // Best N events count towards the total, 0 counts all
+	QualifiedPlayers    int `json:"qualified_players"`
+}
+
+type SeasonStandings struct {
+	Season     string            `json:"season"`
+	Rules      SeasonPointsRules `json:"rules"`
+	EventCount int               `json:"event_count"`
+	CutLine    int               `json:"cut_line"`
+	Entries    []StandingsEntry  `json:"entries"`
+}
+
+type StandingsEntry struct {
+	Rank           int    `json:"rank"`
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	Points         int    `json:"points"`
+	TotalPoints    int    `json:"total_points"`
+	EventsCounted  int    `json:"events_counted"`
+	EventsAttended int    `json:"events_attended"`
+	MatchesWon     int    `json:"matches_won"`
+	MatchesDrawn   int    `json:"matches_drawn"`
+	Qualified      bool   `json:"qualified"`
 }
 
 type PlayerListEntry struct {
@@ -185,3 +262,72 @@ type Decklist struct {
 	Sideboard      []DecklistCard `json:"sideboard,omitempty"`
 	SideboardCount int            `json:"sideboard_count"`
 }
+
+type DecklistLine struct {
+	Line       int          `json:"line"`
+	Text       string       `json:"text"`
+	Card       DecklistCard `json:"card"`
+	Sideboard  bool         `json:"sideboard"`
+	Similarity float64      `json:"similarity"`
+	Uncertain  bool         `json:"uncertain"`
+}
+
+type UnresolvedDecklistLine struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+type ParsedDecklist struct {
+	Decklist   Decklist                 `json:"decklist"`
+	Lines      []DecklistLine           `json:"lines"`
+	Unresolved []UnresolvedDecklistLine `json:"unresolved"`
+}
+
+type PredictionReport struct {
+	Matches          int     `json:"matches"`
+	Upsets           int     `json:"upsets"`
+	EloBrierScore    float64 `json:"elo_brier_score"`
+	GlickoBrierScore float64 `json:"glicko_brier_score"`
+}
+
+type EventPrediction struct {
+	Name       string           `json:"name"`
+	Date       string           `json:"date"`
+	URL        string           `json:"url"`
+	Prediction PredictionReport `json:"prediction"`
+}
+
+type CalibrationBucket struct {
+	Range           string  `json:"range"`
+	Matches         int     `json:"matches"`
+	ExpectedWinRate float64 `json:"expected_win_rate"`
+	ActualWinRate   float64 `json:"actual_win_rate"`
+}
+
+type Upset struct {
+	Date           string  `json:"date"`
+	Winner         string  `json:"winner"`
+	Loser          string  `json:"loser"`
+	Result         string  `json:"result"`
+	WinProbability float64 `json:"win_probability"` // The winner's pre-match Elo chance to win
+	URL            string  `json:"url"`
+}
+
+type RatingModeReport struct {
+	Mode             string  `json:"mode"`
+	Active           bool    `json:"active"`
+	Matches          int     `json:"matches"`
+	EloBrierScore    float64 `json:"elo_brier_score"`
+	GlickoBrierScore float64 `json:"glicko_brier_score"`
+	EloAccuracy      float64 `json:"elo_accuracy"`    // Percentage of decided matches won by the Elo favourite
+	GlickoAccuracy   float64 `json:"glicko_accuracy"` // Percentage of decided matches won by the Glicko2 favourite
+}
+
+type PredictionsOverview struct {
+	Overall     PredictionReport    `json:"overall"`
+	Calibration []CalibrationBucket `json:"calibration"`
+	Events      []EventPrediction   `json:"events"`
+	Upsets      []Upset             `json:"upsets"`
+	RatingModes []RatingModeReport  `json:"rating_modes"`
+}
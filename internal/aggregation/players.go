@@ -10,10 +10,6 @@ import (
 	"slices"
 	"sort"
 	"strings"
-
-	"premodernonsdagar/internal/utils"
-	elogo "premodernonsdagar/pkg/elo"
-	"premodernonsdagar/pkg/glicko2"
 )
 
 func (o *GlickoOpponent) R() float64     { return o.rating }
@@ -89,29 +85,30 @@ func createDeckMatchups(deckStats map[string]*DeckStats) []StatsContainer {
 	return matchups
 }
 
-func aggregatePlayerStats() error {
-	eloCalc := elogo.NewElo()
+func (a *aggregator) aggregatePlayerStats(ratingMode string) error {
+	replay := newRatingReplay(ratingMode)
 
+	// Players are keyed on their ID, the event files have their display names
 	players := make(map[string]*PlayerStats)
 
-	err := os.MkdirAll("files/players", 0755)
+	err := os.MkdirAll(a.outputPath("players"), 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create players directory: %w", err)
 	}
 
-	err = os.MkdirAll("files/events", 0755)
+	err = os.MkdirAll(a.outputPath("events"), 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create events directory: %w", err)
 	}
 
-	err = os.MkdirAll("files/lists", 0755)
+	err = os.MkdirAll(a.outputPath("lists"), 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create lists directory: %w", err)
 	}
 
 	// Collect existing player JSON files
 	existingFiles := make(map[string]bool)
-	err = filepath.WalkDir("files/players", func(path string, d fs.DirEntry, err error) error {
+	err = filepath.WalkDir(a.outputPath("players"), func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -125,7 +122,10 @@ func aggregatePlayerStats() error {
 	}
 
 	eventFiles := []string{}
-	err = filepath.WalkDir("files/events", func(path string, d fs.DirEntry, err error) error {
+	seasonEventCount := make(map[string]int)
+	lastEventInSeason := make(map[string]string)
+	lastEventDate, currentSeason := "", ""
+	err = filepath.WalkDir(a.outputPath("events"), func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -141,17 +141,14 @@ func aggregatePlayerStats() error {
 
 		for _, match := range eventData.Matches {
 			for _, name := range []string{match.Player1, match.Player2} {
-				if _, exists := players[name]; !exists {
-					players[name] = &PlayerStats{
-						Name:        name,
-						WonAgainst:  make(map[string]int),
-						LostAgainst: make(map[string]int),
-						EloRating:   1500,
-						GlickoRating: GlickoStats{
-							Rating: 1500,
-							RD:     350,
-							Sigma:  0.06,
-						},
+				id := a.registry.ID(name)
+				if _, exists := players[id]; !exists {
+					players[id] = &PlayerStats{
+						WonAgainst:   make(map[string]int),
+						LostAgainst:  make(map[string]int),
+						EloRating:    initialEloRating,
+						GlickoRating: initialGlickoRating,
+						Decks:        make(map[string]bool),
 						EloHistory: []HistoryEntry{
 							{Date: "Unranked", Score: 1500},
 						},
@@ -160,10 +157,19 @@ func aggregatePlayerStats() error {
 						},
 					}
 				}
+				players[id].Name = name
 			}
 		}
 
 		eventFiles = append(eventFiles, path)
+		seasonEventCount[eventData.Season]++
+		if eventData.Date > lastEventInSeason[eventData.Season] {
+			lastEventInSeason[eventData.Season] = eventData.Date
+		}
+		if eventData.Date > lastEventDate {
+			lastEventDate = eventData.Date
+			currentSeason = eventData.Season
+		}
 
 		return nil
 	})
@@ -175,6 +181,8 @@ func aggregatePlayerStats() error {
 	sort.Strings(eventFiles)
 
 	decks := make(map[string]map[string]*DeckStats)
+	replayedEvents := []Event{}
+	seasonAttendance := make(map[string]map[string]int)
 
 	// Process each event
 	for _, eventPath := range eventFiles {
@@ -185,15 +193,18 @@ func aggregatePlayerStats() error {
 
 		eventPlayerData := make(map[string]*PlayerEventData)
 
-		for _, match := range eventData.Matches {
-			if _, exists := eventPlayerData[match.Player1]; !exists {
-				eventPlayerData[match.Player1] = &PlayerEventData{
+		for i, match := range eventData.Matches {
+			topRated := topRatedPlayer(players)
+			player1, player2 := a.registry.ID(match.Player1), a.registry.ID(match.Player2)
+
+			if _, exists := eventPlayerData[player1]; !exists {
+				eventPlayerData[player1] = &PlayerEventData{
 					TotalMatchesPlayed: 0,
 					TotalWins:          0,
 				}
 			}
-			if _, exists := eventPlayerData[match.Player2]; !exists {
-				eventPlayerData[match.Player2] = &PlayerEventData{
+			if _, exists := eventPlayerData[player2]; !exists {
+				eventPlayerData[player2] = &PlayerEventData{
 					TotalMatchesPlayed: 0,
 					TotalWins:          0,
 				}
@@ -204,34 +215,39 @@ func aggregatePlayerStats() error {
 			isExtraForP2 := slices.Contains(match.ExtraMatch, match.Player2)
 
 			if !isExtraForP1 {
-				eventPlayerData[match.Player1].TotalMatchesPlayed++
+				eventPlayerData[player1].TotalMatchesPlayed++
 			}
 			if !isExtraForP2 {
-				eventPlayerData[match.Player2].TotalMatchesPlayed++
+				eventPlayerData[player2].TotalMatchesPlayed++
 			}
 
 			result := ParseMatchResult(match)
+			winner, loser := a.registry.ID(result.Winner), a.registry.ID(result.Loser)
 
 			if result.Draw {
-				players[match.Player1].MatchesDrawn++
-				players[match.Player2].MatchesDrawn++
+				players[player1].MatchesDrawn++
+				players[player2].MatchesDrawn++
 			} else {
 				if !isExtraForP1 && result.Winner == match.Player1 {
-					eventPlayerData[result.Winner].TotalWins++
+					eventPlayerData[winner].TotalWins++
 				}
 				if !isExtraForP2 && result.Winner == match.Player2 {
-					eventPlayerData[result.Winner].TotalWins++
+					eventPlayerData[winner].TotalWins++
 				}
-				players[result.Winner].MatchesWon++
-				players[result.Loser].MatchesLost++
+				players[winner].MatchesWon++
+				players[loser].MatchesLost++
 
-				players[result.Winner].WonAgainst[result.Loser]++
-				players[result.Loser].LostAgainst[result.Winner]++
+				players[winner].WonAgainst[loser]++
+				players[loser].LostAgainst[winner]++
+
+				if loser == topRated {
+					players[winner].BeatTopRated = true
+				}
 			}
 
 			for _, p := range []string{match.Player1, match.Player2} {
 				if slices.Contains(match.ExtraMatch, p) {
-					players[p].ExtraMatchesPlayed++
+					players[a.registry.ID(p)].ExtraMatchesPlayed++
 				}
 			}
 
@@ -240,145 +256,120 @@ func aggregatePlayerStats() error {
 				p1Games, p2Games := 0, 0
 				fmt.Sscanf(result.Score, "%d-%d", &p1Games, &p2Games)
 
-				players[match.Player1].GamesWon += p1Games
-				players[match.Player1].GamesLost += p2Games
-				players[match.Player2].GamesWon += p2Games
-				players[match.Player2].GamesLost += p1Games
-
-				players[match.Player1].TotalGamesPlayed += p1Games + p2Games
-				players[match.Player2].TotalGamesPlayed += p1Games + p2Games
-			}
-
-			players[match.Player1].TotalMatchesPlayed++
-			players[match.Player2].TotalMatchesPlayed++
+				players[player1].GamesWon += p1Games
+				players[player1].GamesLost += p2Games
+				players[player2].GamesWon += p2Games
+				players[player2].GamesLost += p1Games
 
-			// Update ELO ratings
-			eloScore := 0.5 // Draw by default
-			if !result.Draw {
-				if result.Winner == match.Player1 {
-					eloScore = 1.0
-				} else {
-					eloScore = 0.0
-				}
+				players[player1].TotalGamesPlayed += p1Games + p2Games
+				players[player2].TotalGamesPlayed += p1Games + p2Games
 			}
 
-			p1OutcomeElo, p2OutcomeElo := eloCalc.Outcome(players[match.Player1].EloRating, players[match.Player2].EloRating, eloScore)
-			players[match.Player1].EloRating = p1OutcomeElo.Rating
-			players[match.Player2].EloRating = p2OutcomeElo.Rating
+			players[player1].TotalMatchesPlayed++
+			players[player2].TotalMatchesPlayed++
 
-			// Glicko-2: needs to be done after processing all matches
+			eloProbability, glickoProbability := replay.playMatch(player1, player2, match)
+			eventData.Matches[i].EloWinProbability = winProbability(eloProbability)
+			eventData.Matches[i].GlickoWinProbability = winProbability(glickoProbability)
+			players[player1].EloRating = replay.elo[player1]
+			players[player2].EloRating = replay.elo[player2]
 		}
 
 		for _, result := range eventData.Results {
 			if result.Deck != "" {
-				if _, exists := decks[result.Name]; !exists {
-					decks[result.Name] = make(map[string]*DeckStats)
+				id := a.registry.ID(result.Name)
+				players[id].Decks[normalizeDeckName(result.Deck)] = true
+				if _, exists := decks[id]; !exists {
+					decks[id] = make(map[string]*DeckStats)
 				}
-				if _, exists := decks[result.Name][result.Deck]; !exists {
-					decks[result.Name][result.Deck] = &DeckStats{}
+				if _, exists := decks[id][result.Deck]; !exists {
+					decks[id][result.Deck] = &DeckStats{}
 				}
-				decks[result.Name][result.Deck].Wins += eventPlayerData[result.Name].TotalWins
-				decks[result.Name][result.Deck].Losses += eventPlayerData[result.Name].TotalMatchesPlayed - eventPlayerData[result.Name].TotalWins
+				decks[id][result.Deck].Wins += eventPlayerData[id].TotalWins
+				decks[id][result.Deck].Losses += eventPlayerData[id].TotalMatchesPlayed - eventPlayerData[id].TotalWins
 			}
 		}
 
-		for name := range eventPlayerData {
-			players[name].AttendedEvents++
+		for id := range eventPlayerData {
+			players[id].AttendedEvents++
 
-			if eventPlayerData[name].TotalMatchesPlayed < eventData.Rounds {
-				players[name].UnfinishedEvents++
+			if eventPlayerData[id].TotalMatchesPlayed < eventData.Rounds {
+				players[id].UnfinishedEvents++
 
-			} else if eventPlayerData[name].TotalWins == eventData.Rounds &&
-				players[name].MatchesDrawn == 0 {
-				players[name].UndefeatedEvents++
+			} else if eventPlayerData[id].TotalWins == eventData.Rounds &&
+				players[id].MatchesDrawn == 0 {
+				players[id].UndefeatedEvents++
 			}
 		}
 
-		playerMatchesInEvent := make(map[string][]GlickoOpponent)
-
-		for _, match := range eventData.Matches {
-			result := ParseMatchResult(match)
-
-			// Score for player 1
-			scoreP1 := 0.5 // Draw by default
-			if !result.Draw {
-				if result.Winner == match.Player1 {
-					scoreP1 = 1.0
-				} else {
-					scoreP1 = 0.0
-				}
-			}
-
-			playerMatchesInEvent[match.Player1] = append(playerMatchesInEvent[match.Player1], GlickoOpponent{
-				rating: players[match.Player2].GlickoRating.Rating,
-				rd:     players[match.Player2].GlickoRating.RD,
-				sigma:  players[match.Player2].GlickoRating.Sigma,
-				score:  scoreP1,
-			})
+		prediction := predictionReport(eventData.Matches)
+		eventData.Prediction = &prediction
+		if err := writeEventFile(eventPath, eventData); err != nil {
+			return err
+		}
+		replayedEvents = append(replayedEvents, *eventData)
 
-			playerMatchesInEvent[match.Player2] = append(playerMatchesInEvent[match.Player2], GlickoOpponent{
-				rating: players[match.Player1].GlickoRating.Rating,
-				rd:     players[match.Player1].GlickoRating.RD,
-				sigma:  players[match.Player1].GlickoRating.Sigma,
-				score:  1.0 - scoreP1, // Reverse score for player 2
-			})
+		// Glicko-2 ratings only change between events
+		replay.endEvent()
+		for id := range eventPlayerData {
+			players[id].GlickoRating = replay.glicko[id]
 		}
 
-		// Update Glicko-2 ratings for each player
-		for player, opponents := range playerMatchesInEvent {
-			if len(opponents) > 0 {
-				// Convert our GlickoOpponent to the glicko2.Opponent interface
-				glickoOpponents := make([]glicko2.Opponent, len(opponents))
-				for i, opp := range opponents {
-					oppCopy := opp // Create a copy to avoid issues with pointer reuse
-					glickoOpponents[i] = &oppCopy
-				}
+		if _, exists := seasonAttendance[eventData.Season]; !exists {
+			seasonAttendance[eventData.Season] = make(map[string]int)
+		}
+		for _, id := range eventData.Attendees {
+			seasonAttendance[eventData.Season][id]++
+		}
 
-				// Update rating
-				nr, nrd, nsigma := glicko2.Rank(
-					players[player].GlickoRating.Rating,
-					players[player].GlickoRating.RD,
-					players[player].GlickoRating.Sigma,
-					glickoOpponents,
-					0.6, // Tau value (recommended between 0.3 and 1.2)
-				)
-
-				players[player].GlickoRating.Rating = nr
-				players[player].GlickoRating.RD = nrd
-				players[player].GlickoRating.Sigma = nsigma
-			} else {
-				// If player skipped this tournament, update RD
-				newRD := glicko2.Skip(
-					players[player].GlickoRating.Rating,
-					players[player].GlickoRating.RD,
-					players[player].GlickoRating.Sigma,
-				)
-				players[player].GlickoRating = GlickoStats{
-					Rating: players[player].GlickoRating.Rating,
-					RD:     newRD,
-					Sigma:  players[player].GlickoRating.Sigma,
+		// A season can only be attended in full once it is over. Players who never played a match have no stats.
+		if eventData.Season != currentSeason && eventData.Date == lastEventInSeason[eventData.Season] {
+			for id, attended := range seasonAttendance[eventData.Season] {
+				if stats, exists := players[id]; exists && attended == seasonEventCount[eventData.Season] {
+					stats.FullSeasons++
+					awardAchievements(stats, eventData.Date)
 				}
 			}
 		}
-		for name := range eventPlayerData {
-			players[name].EloHistory = append(players[name].EloHistory, HistoryEntry{
+
+		for id := range eventPlayerData {
+			players[id].EloHistory = append(players[id].EloHistory, HistoryEntry{
 				Date:  eventData.Date,
-				Score: float64(players[name].EloRating),
+				Score: float64(players[id].EloRating),
 			})
-			players[name].GlickoHistory = append(players[name].GlickoHistory, HistoryEntry{
+			players[id].GlickoHistory = append(players[id].GlickoHistory, HistoryEntry{
 				Date:  eventData.Date,
-				Score: math.Round(players[name].GlickoRating.Rating*100) / 100,
+				Score: math.Round(players[id].GlickoRating.Rating*100) / 100,
 			})
-			players[name].WinRateHistory = append(players[name].WinRateHistory, HistoryEntry{
+			players[id].WinRateHistory = append(players[id].WinRateHistory, HistoryEntry{
 				Date:  eventData.Date,
-				Score: math.Round(float64(players[name].MatchesWon)/float64(players[name].MatchesWon+players[name].MatchesLost+players[name].MatchesDrawn)*10000) / 100,
+				Score: math.Round(float64(players[id].MatchesWon)/float64(players[id].MatchesWon+players[id].MatchesLost+players[id].MatchesDrawn)*10000) / 100,
 			})
+
+			awardAchievements(players[id], eventData.Date)
 		}
 	}
 
+	if err := a.generatePredictionsOverview(replayedEvents, ratingMode); err != nil {
+		return err
+	}
+
+	if err := a.generateAchievementsOverview(players); err != nil {
+		return err
+	}
+
 	playersList := []PlayerListEntry{}
 
-	for name, stats := range players {
+	// Opponents are counted by their ID and shown with their display name
+	opponentNames := func(counts map[string]int) map[string]int {
+		byName := make(map[string]int, len(counts))
+		for id, count := range counts {
+			byName[players[id].Name] += count
+		}
+		return byName
+	}
+
+	for id, stats := range players {
 		if stats.TotalMatchesPlayed == 0 {
 			continue
 		}
@@ -394,47 +385,49 @@ func aggregatePlayerStats() error {
 		}
 
 		player := &Player{
-			Name:             name,
+			Name:             stats.Name,
 			AttendedEvents:   stats.AttendedEvents,
 			UndefeatedEvents: stats.UndefeatedEvents,
 			UnfinishedEvents: stats.UnfinishedEvents,
-			EloRating:        players[name].EloRating,
+			EloRating:        stats.EloRating,
 			GlickoRating: GlickoRating{
-				Mu:    math.Round(players[name].GlickoRating.Rating*100) / 100,
-				Phi:   math.Round(players[name].GlickoRating.RD*100) / 100,
-				Sigma: math.Round(players[name].GlickoRating.Sigma*100) / 100,
+				Mu:    math.Round(stats.GlickoRating.Rating*100) / 100,
+				Phi:   math.Round(stats.GlickoRating.RD*100) / 100,
+				Sigma: math.Round(stats.GlickoRating.Sigma*100) / 100,
 			},
 			DrawCounter:        stats.MatchesDrawn,
 			GameWinRate:        math.Round(gameWinRate*100) / 100,
 			MatchWinRate:       math.Round(matchWinRate*100) / 100,
+			MatchesPlayed:      stats.MatchesWon + stats.MatchesLost + stats.MatchesDrawn,
+			MatchesWon:         stats.MatchesWon,
 			ExtraMatchesPlayed: stats.ExtraMatchesPlayed,
-			OpponentMatchups:   createOpponentMatchups(stats.WonAgainst, stats.LostAgainst),
+			OpponentMatchups:   createOpponentMatchups(opponentNames(stats.WonAgainst), opponentNames(stats.LostAgainst)),
 			EloHistory:         stats.EloHistory,
 			GlickoHistory:      stats.GlickoHistory,
 			WinRateHistory:     stats.WinRateHistory,
+			Achievements:       stats.Achievements,
 		}
 
 		// Create deck matchups with win/loss data
-		player.MatchesWithDecks = createDeckMatchups(decks[name])
+		player.MatchesWithDecks = createDeckMatchups(decks[id])
 
 		playerJSON, err := json.MarshalIndent(player, "", "  ")
 		if err != nil {
-			return fmt.Errorf("failed to marshal player %s: %w", name, err)
+			return fmt.Errorf("failed to marshal player %s: %w", stats.Name, err)
 		}
 
-		slug := utils.Slugify(name)
-		filePath := fmt.Sprintf("files/players/%s.json", slug)
+		filePath := a.outputPath("players", id+".json")
 		err = os.WriteFile(filePath, playerJSON, 0644)
 		if err != nil {
-			return fmt.Errorf("failed to write player file for %s: %w", name, err)
+			return fmt.Errorf("failed to write player file for %s: %w", stats.Name, err)
 		}
 
 		delete(existingFiles, filePath)
 
 		playersList = append(playersList, PlayerListEntry{
-			Name: name,
-			Slug: slug,
-			URL:  "/players/" + slug,
+			Name: stats.Name,
+			Slug: id,
+			URL:  a.siteURL("/players/" + id),
 		})
 	}
 
@@ -455,7 +448,7 @@ func aggregatePlayerStats() error {
 		return fmt.Errorf("failed to marshal players list: %w", err)
 	}
 
-	err = os.WriteFile("files/lists/players.json", playersListJSON, 0644)
+	err = os.WriteFile(a.outputPath("lists", "players.json"), playersListJSON, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write players list file: %w", err)
 	}
@@ -477,6 +470,19 @@ func readEventFile(path string) (*Event, error) {
 	return &eventData, nil
 }
 
+func writeEventFile(path string, eventData *Event) error {
+	data, err := json.MarshalIndent(eventData, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal event file %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write event file %s: %w", path, err)
+	}
+
+	return nil
+}
+
 func ParseMatchResult(match Match) MatchResult {
 	parts := strings.Split(match.Result, "-")
 	if len(parts) != 2 {
@@ -0,0 +1,208 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// matchScore returns the score for player 1, 1 for a win, 0 for a loss and 0.5 for a draw
+func matchScore(match Match) float64 {
+	result := ParseMatchResult(match)
+	if result.Draw {
+		return 0.5
+	}
+	if result.Winner == match.Player1 {
+		return 1.0
+	}
+	return 0.0
+}
+
+// winnerProbability returns the winner's pre-match Elo chance to win, and false for draws and matches without a prediction
+func winnerProbability(match Match) (float64, bool) {
+	result := ParseMatchResult(match)
+	if result.Draw || match.EloWinProbability == nil {
+		return 0, false
+	}
+	if result.Winner == match.Player1 {
+		return *match.EloWinProbability, true
+	}
+	return 1 - *match.EloWinProbability, true
+}
+
+func isUpset(match Match) bool {
+	probability, decided := winnerProbability(match)
+	return decided && probability < 0.5
+}
+
+func roundProbability(p float64) float64 {
+	return math.Round(p*10000) / 10000
+}
+
+// winProbability rounds a pre-match chance to win for storing in a match
+func winProbability(p float64) *float64 {
+	p = roundProbability(p)
+	return &p
+}
+
+// predictionReport scores how well the pre-match probabilities predicted the results, lower Brier scores are better
+func predictionReport(matches []Match) PredictionReport {
+	report := PredictionReport{}
+	eloSquaredError := 0.0
+	glickoSquaredError := 0.0
+
+	for _, match := range matches {
+		if match.EloWinProbability == nil || match.GlickoWinProbability == nil {
+			continue
+		}
+
+		score := matchScore(match)
+		eloSquaredError += math.Pow(*match.EloWinProbability-score, 2)
+		glickoSquaredError += math.Pow(*match.GlickoWinProbability-score, 2)
+		report.Matches++
+
+		if isUpset(match) {
+			report.Upsets++
+		}
+	}
+
+	if report.Matches > 0 {
+		report.EloBrierScore = roundProbability(eloSquaredError / float64(report.Matches))
+		report.GlickoBrierScore = roundProbability(glickoSquaredError / float64(report.Matches))
+	}
+
+	return report
+}
+
+// calibrationBuckets groups decided matches by the favourite's Elo chance to win and compares it to how often the favourite won
+func calibrationBuckets(matches []Match) []CalibrationBucket {
+	bucketCount := 5
+	expected := make([]float64, bucketCount)
+	actual := make([]float64, bucketCount)
+	counts := make([]int, bucketCount)
+
+	for _, match := range matches {
+		if match.EloWinProbability == nil {
+			continue
+		}
+
+		favouriteProbability := *match.EloWinProbability
+		favouriteScore := matchScore(match)
+		if favouriteProbability < 0.5 {
+			favouriteProbability = 1 - favouriteProbability
+			favouriteScore = 1 - favouriteScore
+		}
+
+		bucket := min(int((favouriteProbability-0.5)*10), bucketCount-1)
+		expected[bucket] += favouriteProbability
+		actual[bucket] += favouriteScore
+		counts[bucket]++
+	}
+
+	buckets := make([]CalibrationBucket, 0, bucketCount)
+	for i := range bucketCount {
+		bucket := CalibrationBucket{
+			Range:   fmt.Sprintf("%d-%d%%", 50+i*10, 60+i*10),
+			Matches: counts[i],
+		}
+		if counts[i] > 0 {
+			bucket.ExpectedWinRate = math.Round(expected[i]/float64(counts[i])*10000) / 100
+			bucket.ActualWinRate = math.Round(actual[i]/float64(counts[i])*10000) / 100
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets
+}
+
+// collectUpsets lists all matches won by the underdog, biggest upset first
+func (a *aggregator) collectUpsets(events []Event) []Upset {
+	upsets := []Upset{}
+	for _, event := range events {
+		for _, match := range event.Matches {
+			if !isUpset(match) {
+				continue
+			}
+
+			result := ParseMatchResult(match)
+			probability, _ := winnerProbability(match)
+			upsets = append(upsets, Upset{
+				Date:           event.Date,
+				Winner:         result.Winner,
+				Loser:          result.Loser,
+				Result:         match.Result,
+				WinProbability: probability,
+				URL:            a.siteURL("/events/" + event.Date),
+			})
+		}
+	}
+
+	sort.SliceStable(upsets, func(i, j int) bool {
+		if upsets[i].WinProbability != upsets[j].WinProbability {
+			return upsets[i].WinProbability < upsets[j].WinProbability
+		}
+		return upsets[i].Date > upsets[j].Date
+	})
+
+	return upsets
+}
+
+func (a *aggregator) upsetsLeaderboard(events []Event) LeaderboardContainer {
+	upsets := a.collectUpsets(events)
+	// Only the biggest, like the other boards
+	upsets = upsets[:min(len(upsets), 32)]
+
+	entries := []LeaderboardEntry{}
+	for _, upset := range upsets {
+		entries = append(entries, LeaderboardEntry{
+			Name:  fmt.Sprintf("%s def. %s (%s)", upset.Winner, upset.Loser, upset.Date),
+			Score: math.Round(upset.WinProbability*10000) / 100,
+			URL:   upset.URL,
+		})
+	}
+
+	return LeaderboardContainer{
+		Title:   "Biggest Upsets",
+		Entries: entries,
+		Type:    "float",
+		Suffix:  "%",
+	}
+}
+
+func (a *aggregator) generatePredictionsOverview(events []Event, ratingMode string) error {
+	overview := PredictionsOverview{
+		Events:      []EventPrediction{},
+		Upsets:      a.collectUpsets(events),
+		RatingModes: a.compareRatingModes(events, ratingMode),
+	}
+
+	allMatches := []Match{}
+	for _, event := range events {
+		allMatches = append(allMatches, event.Matches...)
+		if event.Prediction == nil {
+			continue
+		}
+		overview.Events = append(overview.Events, EventPrediction{
+			Name:       event.Name,
+			Date:       event.Date,
+			URL:        a.siteURL("/events/" + event.Date),
+			Prediction: *event.Prediction,
+		})
+	}
+
+	overview.Overall = predictionReport(allMatches)
+	overview.Calibration = calibrationBuckets(allMatches)
+
+	output, err := json.MarshalIndent(overview, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal predictions overview: %w", err)
+	}
+
+	if err := os.WriteFile(a.outputPath("lists", "predictions.json"), output, 0644); err != nil {
+		return fmt.Errorf("failed to write predictions overview: %w", err)
+	}
+
+	return nil
+}
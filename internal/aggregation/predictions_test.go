@@ -0,0 +1,59 @@
+package aggregation
+
+import (
+	"testing"
+)
+
+func TestPredictionReport(t *testing.T) {
+	matches := []Match{
+		{Player1: "Alice", Player2: "Bob", Result: "2-0", EloWinProbability: winProbability(0.8), GlickoWinProbability: winProbability(0.9)},
+		{Player1: "Alice", Player2: "Carl", Result: "1-2", EloWinProbability: winProbability(0.6), GlickoWinProbability: winProbability(0.5)},
+		{Player1: "Bob", Player2: "Carl", Result: "1-1", EloWinProbability: winProbability(0.5), GlickoWinProbability: winProbability(0.5)},
+		{Player1: "Bob", Player2: "Dave", Result: "2-0"},
+	}
+
+	report := predictionReport(matches)
+
+	if report.Matches != 3 {
+		t.Errorf("Expected 3 predicted matches, got %d", report.Matches)
+	}
+	if report.Upsets != 1 {
+		t.Errorf("Expected 1 upset, got %d", report.Upsets)
+	}
+	// ((0.2)^2 + (0.6)^2 + 0) / 3
+	if report.EloBrierScore != 0.1333 {
+		t.Errorf("Expected Elo Brier score 0.1333, got %v", report.EloBrierScore)
+	}
+	// ((0.1)^2 + (0.5)^2 + 0) / 3
+	if report.GlickoBrierScore != 0.0867 {
+		t.Errorf("Expected Glicko Brier score 0.0867, got %v", report.GlickoBrierScore)
+	}
+}
+
+func TestCollectUpsets(t *testing.T) {
+	events := []Event{
+		{
+			Date: "2025-09-02",
+			Matches: []Match{
+				{Player1: "Alice", Player2: "Bob", Result: "0-2", EloWinProbability: winProbability(0.7)},
+				{Player1: "Alice", Player2: "Carl", Result: "2-0", EloWinProbability: winProbability(0.35)},
+				{Player1: "Bob", Player2: "Carl", Result: "2-1", EloWinProbability: winProbability(0.9)},
+				{Player1: "Carl", Player2: "Dave", Result: "2-0", EloWinProbability: winProbability(0)},
+				{Player1: "Dave", Player2: "Erik", Result: "2-0"},
+			},
+		},
+	}
+
+	a := testAggregator()
+	upsets := a.collectUpsets(events)
+
+	if len(upsets) != 3 {
+		t.Fatalf("Expected 3 upsets, leaving out the match without a prediction, got %+v", upsets)
+	}
+	if upsets[0].Winner != "Carl" || upsets[0].WinProbability != 0 || upsets[1].Winner != "Bob" || upsets[2].Winner != "Alice" {
+		t.Errorf("Expected upsets ordered by probability, got %+v", upsets)
+	}
+	if upsets[2].Loser != "Carl" || upsets[2].URL != "/events/2025-09-02" {
+		t.Errorf("Unexpected upset %+v", upsets[1])
+	}
+}
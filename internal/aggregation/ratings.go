@@ -0,0 +1,169 @@
+package aggregation
+
+import (
+	"fmt"
+	"math"
+
+	"premodernonsdagar/internal/config"
+	elogo "premodernonsdagar/pkg/elo"
+	"premodernonsdagar/pkg/glicko2"
+)
+
+const (
+	initialEloRating = 1500
+	glickoTau        = 0.6 // Tau value (recommended between 0.3 and 1.2)
+)
+
+var initialGlickoRating = GlickoStats{
+	Rating: 1500,
+	RD:     350,
+	Sigma:  0.06,
+}
+
+// ratingScore returns the score for player 1 used to update the ratings.
+// In game mode a match is scored by the share of games won, so a 2-0 counts for more than a 2-1.
+func ratingScore(match Match, ratingMode string) float64 {
+	score := matchScore(match)
+	if ratingMode != config.RatingModeGame {
+		return score
+	}
+
+	var p1Games, p2Games int
+	if _, err := fmt.Sscanf(match.Result, "%d-%d", &p1Games, &p2Games); err != nil || p1Games+p2Games == 0 {
+		return score
+	}
+
+	return float64(p1Games) / float64(p1Games+p2Games)
+}
+
+// ratingReplay replays the match history in a rating mode, keyed by player ID. Elo ratings change after every
+// match and Glicko-2 ratings after every event.
+type ratingReplay struct {
+	mode      string
+	eloCalc   *elogo.Elo
+	elo       map[string]int
+	glicko    map[string]GlickoStats
+	opponents map[string][]GlickoOpponent // The opponents of each player in the current event
+}
+
+func newRatingReplay(mode string) *ratingReplay {
+	return &ratingReplay{
+		mode:      mode,
+		eloCalc:   elogo.NewElo(),
+		elo:       make(map[string]int),
+		glicko:    make(map[string]GlickoStats),
+		opponents: make(map[string][]GlickoOpponent),
+	}
+}
+
+// playMatch returns the pre-match chances for player 1 to win and updates the Elo ratings with the result
+func (r *ratingReplay) playMatch(player1, player2 string, match Match) (eloProbability, glickoProbability float64) {
+	for _, id := range []string{player1, player2} {
+		if _, exists := r.elo[id]; !exists {
+			r.elo[id] = initialEloRating
+			r.glicko[id] = initialGlickoRating
+		}
+	}
+
+	p1Glicko, p2Glicko := r.glicko[player1], r.glicko[player2]
+	eloProbability = r.eloCalc.ExpectedScore(r.elo[player1], r.elo[player2])
+	glickoProbability = glicko2.ExpectedScore(p1Glicko.Rating, p1Glicko.RD, p2Glicko.Rating, p2Glicko.RD)
+
+	score := ratingScore(match, r.mode)
+	p1Outcome, p2Outcome := r.eloCalc.Outcome(r.elo[player1], r.elo[player2], score)
+	r.elo[player1] = p1Outcome.Rating
+	r.elo[player2] = p2Outcome.Rating
+
+	// Glicko-2 is rated against the opponents' ratings before the event, once the event is over
+	r.opponents[player1] = append(r.opponents[player1], GlickoOpponent{
+		rating: p2Glicko.Rating,
+		rd:     p2Glicko.RD,
+		sigma:  p2Glicko.Sigma,
+		score:  score,
+	})
+	r.opponents[player2] = append(r.opponents[player2], GlickoOpponent{
+		rating: p1Glicko.Rating,
+		rd:     p1Glicko.RD,
+		sigma:  p1Glicko.Sigma,
+		score:  1.0 - score,
+	})
+
+	return eloProbability, glickoProbability
+}
+
+// endEvent updates the Glicko-2 ratings of the players in the event
+func (r *ratingReplay) endEvent() {
+	for player, playerOpponents := range r.opponents {
+		glickoOpponents := make([]glicko2.Opponent, len(playerOpponents))
+		for i := range playerOpponents {
+			glickoOpponents[i] = &playerOpponents[i]
+		}
+
+		current := r.glicko[player]
+		nr, nrd, nsigma := glicko2.Rank(current.Rating, current.RD, current.Sigma, glickoOpponents, glickoTau)
+		r.glicko[player] = GlickoStats{Rating: nr, RD: nrd, Sigma: nsigma}
+	}
+	r.opponents = make(map[string][]GlickoOpponent)
+}
+
+// ratingModeReport replays all events with the given rating mode and reports how well the
+// pre-match ratings predicted the match results
+func (a *aggregator) ratingModeReport(events []Event, ratingMode string) RatingModeReport {
+	replay := newRatingReplay(ratingMode)
+
+	report := RatingModeReport{Mode: ratingMode}
+	eloSquaredError, glickoSquaredError := 0.0, 0.0
+	eloCorrect, glickoCorrect := 0.0, 0.0
+	decided := 0
+
+	for _, event := range events {
+		for _, match := range event.Matches {
+			eloProbability, glickoProbability := replay.playMatch(a.registry.ID(match.Player1), a.registry.ID(match.Player2), match)
+
+			outcome := matchScore(match)
+			eloSquaredError += math.Pow(eloProbability-outcome, 2)
+			glickoSquaredError += math.Pow(glickoProbability-outcome, 2)
+			report.Matches++
+
+			if outcome != 0.5 {
+				decided++
+				eloCorrect += predictionHit(eloProbability, outcome)
+				glickoCorrect += predictionHit(glickoProbability, outcome)
+			}
+		}
+		replay.endEvent()
+	}
+
+	if report.Matches > 0 {
+		report.EloBrierScore = roundProbability(eloSquaredError / float64(report.Matches))
+		report.GlickoBrierScore = roundProbability(glickoSquaredError / float64(report.Matches))
+	}
+	if decided > 0 {
+		report.EloAccuracy = math.Round(eloCorrect/float64(decided)*10000) / 100
+		report.GlickoAccuracy = math.Round(glickoCorrect/float64(decided)*10000) / 100
+	}
+
+	return report
+}
+
+// predictionHit returns 1 if the favourite won, 0 if the underdog won and 0.5 if the players were rated equal
+func predictionHit(probability, outcome float64) float64 {
+	if probability == 0.5 {
+		return 0.5
+	}
+	if (probability > 0.5) == (outcome == 1.0) {
+		return 1
+	}
+	return 0
+}
+
+// compareRatingModes replays the history in every rating mode so the modes can be compared
+func (a *aggregator) compareRatingModes(events []Event, activeMode string) []RatingModeReport {
+	reports := []RatingModeReport{}
+	for _, mode := range []string{config.RatingModeMatch, config.RatingModeGame} {
+		report := a.ratingModeReport(events, mode)
+		report.Active = mode == activeMode
+		reports = append(reports, report)
+	}
+	return reports
+}
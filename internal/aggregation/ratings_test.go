@@ -0,0 +1,58 @@
+package aggregation
+
+import (
+	"testing"
+
+	"premodernonsdagar/internal/config"
+)
+
+func TestRatingScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		mode     string
+		expected float64
+	}{
+		{name: "match mode 2-0", result: "2-0", mode: config.RatingModeMatch, expected: 1.0},
+		{name: "match mode 2-1", result: "2-1", mode: config.RatingModeMatch, expected: 1.0},
+		{name: "match mode loss", result: "1-2", mode: config.RatingModeMatch, expected: 0.0},
+		{name: "game mode 2-0", result: "2-0", mode: config.RatingModeGame, expected: 1.0},
+		{name: "game mode 2-1", result: "2-1", mode: config.RatingModeGame, expected: 2.0 / 3.0},
+		{name: "game mode 0-2", result: "0-2", mode: config.RatingModeGame, expected: 0.0},
+		{name: "game mode draw", result: "1-1", mode: config.RatingModeGame, expected: 0.5},
+		{name: "game mode unparseable", result: "draw", mode: config.RatingModeGame, expected: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := Match{Player1: "Alice", Player2: "Bob", Result: tt.result}
+			result := ratingScore(match, tt.mode)
+			if result != tt.expected {
+				t.Errorf("ratingScore(%q, %q) = %v, want %v", tt.result, tt.mode, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRatingReplay(t *testing.T) {
+	replay := newRatingReplay(config.RatingModeMatch)
+
+	eloProbability, glickoProbability := replay.playMatch("alice", "bob", Match{Player1: "Alice", Player2: "Bob", Result: "2-0"})
+	if eloProbability != 0.5 || glickoProbability != 0.5 {
+		t.Errorf("Expected even chances between new players, got %v and %v", eloProbability, glickoProbability)
+	}
+	if replay.elo["alice"] <= initialEloRating || replay.elo["bob"] >= initialEloRating {
+		t.Errorf("Expected the Elo ratings to change after the match, got %v", replay.elo)
+	}
+	if replay.glicko["alice"] != initialGlickoRating {
+		t.Errorf("Expected the Glicko-2 ratings to change only after the event, got %+v", replay.glicko["alice"])
+	}
+
+	replay.endEvent()
+	if replay.glicko["alice"].Rating <= initialGlickoRating.Rating || replay.glicko["bob"].Rating >= initialGlickoRating.Rating {
+		t.Errorf("Expected the Glicko-2 ratings to change after the event, got %+v", replay.glicko)
+	}
+	if len(replay.opponents) != 0 {
+		t.Error("Expected the opponents to be cleared for the next event")
+	}
+}
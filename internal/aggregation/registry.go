@@ -0,0 +1,388 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"premodernonsdagar/internal/utils"
+)
+
+const playerRegistryPath = "input/players.json"
+
+var ErrPlayerNotFound = errors.New("player not found")
+
+// RegisteredPlayer gives a player a stable ID, so they can be renamed or have misspellings
+// merged into them without rewriting the event files
+type RegisteredPlayer struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases,omitempty"`
+	MergedIDs []string `json:"merged_ids,omitempty"` // IDs of players merged into this one, kept so old links keep working
+}
+
+// PlayerRegistry resolves the names used in event files to registered players.
+// Names that are not registered are their own player, with their slug as ID.
+type PlayerRegistry struct {
+	Players []RegisteredPlayer
+	byName  map[string]int
+}
+
+func NewPlayerRegistry(players []RegisteredPlayer) *PlayerRegistry {
+	r := &PlayerRegistry{Players: players}
+	r.index()
+	return r
+}
+
+func normalizePlayerName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func (r *PlayerRegistry) index() {
+	r.byName = make(map[string]int)
+	for i, player := range r.Players {
+		r.byName[normalizePlayerName(player.Name)] = i
+		for _, alias := range player.Aliases {
+			r.byName[normalizePlayerName(alias)] = i
+		}
+	}
+}
+
+// LoadPlayerRegistry reads the registry, a missing file is an empty registry
+func LoadPlayerRegistry() (*PlayerRegistry, error) {
+	data, err := os.ReadFile(playerRegistryPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewPlayerRegistry(nil), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read player registry: %w", err)
+	}
+
+	var players []RegisteredPlayer
+	if err := json.Unmarshal(data, &players); err != nil {
+		return nil, fmt.Errorf("failed to parse player registry: %w", err)
+	}
+	return NewPlayerRegistry(players), nil
+}
+
+func (r *PlayerRegistry) Save() error {
+	sort.Slice(r.Players, func(i, j int) bool {
+		return r.Players[i].ID < r.Players[j].ID
+	})
+	r.index()
+
+	data, err := json.MarshalIndent(r.Players, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal player registry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(playerRegistryPath), 0755); err != nil {
+		return fmt.Errorf("failed to create input directory: %w", err)
+	}
+	if err := os.WriteFile(playerRegistryPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write player registry: %w", err)
+	}
+	return nil
+}
+
+func (r *PlayerRegistry) lookup(name string) (*RegisteredPlayer, bool) {
+	i, exists := r.byName[normalizePlayerName(name)]
+	if !exists {
+		return nil, false
+	}
+	return &r.Players[i], true
+}
+
+func (r *PlayerRegistry) find(id string) (*RegisteredPlayer, bool) {
+	for i := range r.Players {
+		if r.Players[i].ID == id {
+			return &r.Players[i], true
+		}
+	}
+	return nil, false
+}
+
+// Name returns the display name for a name or alias used in an event file
+func (r *PlayerRegistry) Name(name string) string {
+	if player, exists := r.lookup(name); exists {
+		return player.Name
+	}
+	return name
+}
+
+// ID returns the stable ID for a name or alias used in an event file
+func (r *PlayerRegistry) ID(name string) string {
+	if player, exists := r.lookup(name); exists {
+		return player.ID
+	}
+	return utils.Slugify(name)
+}
+
+// MergedInto returns the ID a merged away player ID now belongs to
+func (r *PlayerRegistry) MergedInto(id string) (string, bool) {
+	for _, player := range r.Players {
+		if slices.Contains(player.MergedIDs, id) {
+			return player.ID, true
+		}
+	}
+	return "", false
+}
+
+func (r *PlayerRegistry) idTaken(id string) bool {
+	if _, exists := r.find(id); exists {
+		return true
+	}
+	_, merged := r.MergedInto(id)
+	return merged
+}
+
+// Register pins the current ID of a name, so it survives renames
+func (r *PlayerRegistry) Register(name string) (*RegisteredPlayer, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return nil, fmt.Errorf("player name cannot be empty")
+	}
+	if player, exists := r.lookup(name); exists {
+		return player, nil
+	}
+
+	base := utils.Slugify(name)
+	if base == "" {
+		return nil, fmt.Errorf("player name %q has no characters usable in an ID", name)
+	}
+	id := base
+	for i := 2; r.idTaken(id); i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	r.Players = append(r.Players, RegisteredPlayer{ID: id, Name: name})
+	r.index()
+	return &r.Players[len(r.Players)-1], nil
+}
+
+// RegisterFromDirectory registers a player listed in the directory if needed and returns the registered ID
+func (r *PlayerRegistry) RegisterFromDirectory(directory []PlayerDirectoryEntry, id string) (string, error) {
+	for _, entry := range directory {
+		if entry.ID != id {
+			continue
+		}
+		if entry.Registered {
+			return entry.ID, nil
+		}
+
+		player, err := r.Register(entry.Name)
+		if err != nil {
+			return "", err
+		}
+		// Pin the other spellings that already resolved to this ID
+		for _, spelling := range entry.Spellings {
+			if err := r.AddAlias(player.ID, spelling); err != nil {
+				return "", err
+			}
+		}
+		return player.ID, nil
+	}
+	return "", ErrPlayerNotFound
+}
+
+// Rename changes the display name and keeps the old name as an alias, so existing event files still resolve
+func (r *PlayerRegistry) Rename(id, name string) error {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return fmt.Errorf("player name cannot be empty")
+	}
+
+	player, exists := r.find(id)
+	if !exists {
+		return ErrPlayerNotFound
+	}
+	if other, exists := r.lookup(name); exists && other.ID != id {
+		return fmt.Errorf("%q is already used by %s, merge the players instead", name, other.ID)
+	}
+
+	if !slices.Contains(player.Aliases, player.Name) && player.Name != name {
+		player.Aliases = append(player.Aliases, player.Name)
+	}
+	player.Aliases = slices.DeleteFunc(player.Aliases, func(alias string) bool {
+		return normalizePlayerName(alias) == normalizePlayerName(name)
+	})
+	player.Name = name
+	r.index()
+	return nil
+}
+
+// AddAlias lets another spelling resolve to the player
+func (r *PlayerRegistry) AddAlias(id, alias string) error {
+	alias = strings.Join(strings.Fields(alias), " ")
+	if alias == "" {
+		return fmt.Errorf("alias cannot be empty")
+	}
+
+	player, exists := r.find(id)
+	if !exists {
+		return ErrPlayerNotFound
+	}
+	if other, exists := r.lookup(alias); exists {
+		if other.ID == id {
+			return nil
+		}
+		return fmt.Errorf("%q is already used by %s, merge the players instead", alias, other.ID)
+	}
+
+	player.Aliases = append(player.Aliases, alias)
+	r.index()
+	return nil
+}
+
+func (r *PlayerRegistry) RemoveAlias(id, alias string) error {
+	player, exists := r.find(id)
+	if !exists {
+		return ErrPlayerNotFound
+	}
+
+	player.Aliases = slices.DeleteFunc(player.Aliases, func(existing string) bool {
+		return existing == alias
+	})
+	r.index()
+	return nil
+}
+
+// Merge moves the source player, with all its names, into the target player
+func (r *PlayerRegistry) Merge(sourceID, targetID string) error {
+	if sourceID == targetID {
+		return fmt.Errorf("cannot merge a player into themselves")
+	}
+
+	source, exists := r.find(sourceID)
+	if !exists {
+		return ErrPlayerNotFound
+	}
+	if _, exists := r.find(targetID); !exists {
+		return ErrPlayerNotFound
+	}
+
+	names := append([]string{source.Name}, source.Aliases...)
+	mergedIDs := append([]string{source.ID}, source.MergedIDs...)
+
+	r.Players = slices.DeleteFunc(r.Players, func(player RegisteredPlayer) bool {
+		return player.ID == sourceID
+	})
+
+	target, _ := r.find(targetID)
+	target.Aliases = append(target.Aliases, names...)
+	target.MergedIDs = append(target.MergedIDs, mergedIDs...)
+	r.index()
+	return nil
+}
+
+// ResolveEvent replaces every name in the event with the registered display name
+func (r *PlayerRegistry) ResolveEvent(event *InputEvent) {
+	for i := range event.Matches {
+		match := &event.Matches[i]
+		match.Player1 = r.Name(match.Player1)
+		match.Player2 = r.Name(match.Player2)
+		for j := range match.ExtraMatch {
+			match.ExtraMatch[j] = r.Name(match.ExtraMatch[j])
+		}
+	}
+
+	playerInfo := make(map[string]PlayerEventInfo, len(event.PlayerInfo))
+	for name, info := range event.PlayerInfo {
+		resolved := r.Name(name)
+		// Prefer the entry that has a deck if the same player is listed under two names
+		if existing, exists := playerInfo[resolved]; exists && existing.Deck != "" {
+			continue
+		}
+		playerInfo[resolved] = info
+	}
+	event.PlayerInfo = playerInfo
+}
+
+// playerSlug returns the ID used in the URL of a player's page
+func (a *aggregator) playerSlug(name string) string {
+	return a.registry.ID(name)
+}
+
+type PlayerDirectoryEntry struct {
+	ID         string
+	Name       string
+	Registered bool
+	Aliases    []string
+	Spellings  []string // The names used for the player in the event files
+	Dates      []string // The events the player attended, by date and series
+}
+
+// PlayerDirectory lists every player in the registry or the event files of any series
+func PlayerDirectory(r *PlayerRegistry) ([]PlayerDirectoryEntry, error) {
+	entries := make(map[string]*PlayerDirectoryEntry)
+	for _, player := range r.Players {
+		entries[player.ID] = &PlayerDirectoryEntry{
+			ID:         player.ID,
+			Name:       player.Name,
+			Registered: true,
+			Aliases:    player.Aliases,
+		}
+	}
+
+	eventFiles, err := allEventFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, eventFile := range eventFiles {
+		data, err := os.ReadFile(eventFile.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event file %s: %w", eventFile.Path, err)
+		}
+		var event InputEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse event file %s: %w", eventFile.Path, err)
+		}
+
+		// Events of different series can be on the same date
+		eventKey := event.Date
+		if !eventFile.Series.Main() {
+			eventKey += " (" + eventFile.Series.Name + ")"
+		}
+
+		names := []string{}
+		for _, match := range event.Matches {
+			names = append(names, match.Player1, match.Player2)
+		}
+		for name := range event.PlayerInfo {
+			names = append(names, name)
+		}
+
+		for _, name := range names {
+			id := r.ID(name)
+			entry, exists := entries[id]
+			if !exists {
+				entry = &PlayerDirectoryEntry{ID: id, Name: name}
+				entries[id] = entry
+			}
+			if !slices.Contains(entry.Spellings, name) {
+				entry.Spellings = append(entry.Spellings, name)
+			}
+			if !slices.Contains(entry.Dates, eventKey) {
+				entry.Dates = append(entry.Dates, eventKey)
+			}
+		}
+	}
+
+	directory := make([]PlayerDirectoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		sort.Strings(entry.Spellings)
+		sort.Strings(entry.Dates)
+		directory = append(directory, *entry)
+	}
+	sort.Slice(directory, func(i, j int) bool {
+		return strings.ToLower(directory[i].Name) < strings.ToLower(directory[j].Name)
+	})
+
+	return directory, nil
+}
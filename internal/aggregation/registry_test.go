@@ -0,0 +1,119 @@
+package aggregation
+
+import (
+	"testing"
+)
+
+func TestPlayerRegistryUnregisteredNames(t *testing.T) {
+	r := NewPlayerRegistry(nil)
+
+	if id := r.ID("Anna Svensson"); id != "anna-svensson" {
+		t.Errorf("Expected unregistered ID anna-svensson, got %s", id)
+	}
+	if name := r.Name("Anna Svensson"); name != "Anna Svensson" {
+		t.Errorf("Expected unregistered name to be unchanged, got %s", name)
+	}
+}
+
+func TestPlayerRegistryRename(t *testing.T) {
+	r := NewPlayerRegistry(nil)
+	player, err := r.Register("Anna Svensson")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if err := r.Rename(player.ID, "Anna Berg"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	for _, name := range []string{"Anna Svensson", "anna  svensson", "Anna Berg"} {
+		if id := r.ID(name); id != "anna-svensson" {
+			t.Errorf("Expected %q to keep ID anna-svensson, got %s", name, id)
+		}
+		if resolved := r.Name(name); resolved != "Anna Berg" {
+			t.Errorf("Expected %q to resolve to Anna Berg, got %s", name, resolved)
+		}
+	}
+
+	r.Register("Erik Berg")
+	if err := r.Rename("erik-berg", "Anna Berg"); err == nil {
+		t.Error("Expected renaming to a name used by another player to fail")
+	}
+}
+
+func TestPlayerRegistryAliases(t *testing.T) {
+	r := NewPlayerRegistry([]RegisteredPlayer{{ID: "anna", Name: "Anna"}, {ID: "erik", Name: "Erik"}})
+
+	if err := r.AddAlias("anna", "Ana"); err != nil {
+		t.Fatalf("AddAlias failed: %v", err)
+	}
+	if name := r.Name("ana"); name != "Anna" {
+		t.Errorf("Expected alias to resolve to Anna, got %s", name)
+	}
+	if err := r.AddAlias("anna", "Erik"); err == nil {
+		t.Error("Expected an alias used by another player to fail")
+	}
+	if err := r.AddAlias("missing", "Someone"); err != ErrPlayerNotFound {
+		t.Errorf("Expected ErrPlayerNotFound, got %v", err)
+	}
+
+	if err := r.RemoveAlias("anna", "Ana"); err != nil {
+		t.Fatalf("RemoveAlias failed: %v", err)
+	}
+	if id := r.ID("Ana"); id != "ana" {
+		t.Errorf("Expected removed alias to fall back to its own ID, got %s", id)
+	}
+}
+
+func TestPlayerRegistryMerge(t *testing.T) {
+	r := NewPlayerRegistry(nil)
+	r.Register("Anna Svensson")
+	r.Register("Ana Svensson")
+
+	if err := r.Merge("ana-svensson", "anna-svensson"); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if id := r.ID("Ana Svensson"); id != "anna-svensson" {
+		t.Errorf("Expected merged name to resolve to anna-svensson, got %s", id)
+	}
+	if target, merged := r.MergedInto("ana-svensson"); !merged || target != "anna-svensson" {
+		t.Errorf("Expected ana-svensson to be merged into anna-svensson, got %q %v", target, merged)
+	}
+	if len(r.Players) != 1 {
+		t.Errorf("Expected 1 registered player after merge, got %d", len(r.Players))
+	}
+
+	// A new player cannot take the ID of a merged player, as it still redirects
+	player, _ := r.Register("Ana-Svensson!")
+	if player.ID == "ana-svensson" {
+		t.Error("Expected a merged ID not to be reused")
+	}
+
+	if err := r.Merge("anna-svensson", "anna-svensson"); err == nil {
+		t.Error("Expected merging a player into themselves to fail")
+	}
+}
+
+func TestPlayerRegistryResolveEvent(t *testing.T) {
+	r := NewPlayerRegistry([]RegisteredPlayer{{ID: "anna", Name: "Anna", Aliases: []string{"Ana"}}})
+	event := InputEvent{
+		Matches: []Match{{Player1: "Ana", Player2: "Erik", Result: "2-0", ExtraMatch: []string{"Ana"}}},
+		PlayerInfo: map[string]PlayerEventInfo{
+			"Ana":  {Deck: "Goblins"},
+			"Erik": {Deck: "Stiflenought"},
+		},
+	}
+
+	r.ResolveEvent(&event)
+
+	if event.Matches[0].Player1 != "Anna" || event.Matches[0].ExtraMatch[0] != "Anna" {
+		t.Errorf("Expected match names to be resolved, got %+v", event.Matches[0])
+	}
+	if event.PlayerInfo["Anna"].Deck != "Goblins" {
+		t.Errorf("Expected player info to be keyed on the resolved name, got %+v", event.PlayerInfo)
+	}
+	if _, exists := event.PlayerInfo["Ana"]; exists {
+		t.Error("Expected the alias to be removed from player info")
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"time"
 )
 
-func GetSeason(date string, firstEventDate string) (string, error) {
+func GetSeason(date string, firstEventDate string, months int) (string, error) {
 	eventDate, err := time.Parse("2006-01-02", date)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse event date: %w", err)
@@ -17,38 +17,21 @@ func GetSeason(date string, firstEventDate string) (string, error) {
 		return "", fmt.Errorf("failed to parse first event date: %w", err)
 	}
 
-	// Seasons are January-June or July-December, starting from the first event
-	firstMonth := firstDate.Month()
-	firstYear := firstDate.Year()
-
-	var season1Start time.Time
-	if firstMonth <= 6 {
-		season1Start = time.Date(firstYear, 1, 1, 0, 0, 0, 0, time.UTC)
-	} else {
-		season1Start = time.Date(firstYear, 7, 1, 0, 0, 0, 0, time.UTC)
+	// Seasons split the year into equal parts, January-June and July-December by default, starting from the first event
+	if months <= 0 {
+		months = 6
 	}
+	firstSeasonMonth := (int(firstDate.Month())-1)/months*months + 1
+	firstSeasonStart := time.Date(firstDate.Year(), time.Month(firstSeasonMonth), 1, 0, 0, 0, 0, time.UTC)
 
-	seasonNumber := 1
-	currentSeasonStart := season1Start
-
-	for {
-		var nextSeasonStart time.Time
-		if currentSeasonStart.Month() == 1 {
-			nextSeasonStart = time.Date(currentSeasonStart.Year(), 7, 1, 0, 0, 0, 0, time.UTC)
-		} else {
-			nextSeasonStart = time.Date(currentSeasonStart.Year()+1, 1, 1, 0, 0, 0, 0, time.UTC)
-		}
-
-		if eventDate.Before(nextSeasonStart) {
-			return fmt.Sprintf("s%02d", seasonNumber), nil
-		}
-
-		currentSeasonStart = nextSeasonStart
-		seasonNumber++
+	monthsSinceStart := (eventDate.Year()-firstSeasonStart.Year())*12 + int(eventDate.Month()) - int(firstSeasonStart.Month())
+	if monthsSinceStart < 0 {
+		return "", fmt.Errorf("event %s is before the first event %s", date, firstEventDate)
 	}
+	return fmt.Sprintf("s%02d", monthsSinceStart/months+1), nil
 }
 
-func GetAllSeasons(eventDates []string) ([]string, error) {
+func GetAllSeasons(eventDates []string, months int) ([]string, error) {
 	if len(eventDates) == 0 {
 		return []string{}, nil
 	}
@@ -60,7 +43,7 @@ func GetAllSeasons(eventDates []string) ([]string, error) {
 
 	seasonsMap := make(map[string]bool)
 	for _, date := range eventDates {
-		season, err := GetSeason(date, firstEventDate)
+		season, err := GetSeason(date, firstEventDate, months)
 		if err != nil {
 			return nil, err
 		}
@@ -76,7 +59,7 @@ func GetAllSeasons(eventDates []string) ([]string, error) {
 	return seasons, nil
 }
 
-func GetCurrentSeason(eventDates []string) (string, error) {
+func GetCurrentSeason(eventDates []string, months int) (string, error) {
 	if len(eventDates) == 0 {
 		return "", fmt.Errorf("no events available")
 	}
@@ -88,5 +71,5 @@ func GetCurrentSeason(eventDates []string) (string, error) {
 	firstEventDate := sortedDates[0]
 	lastEventDate := sortedDates[len(sortedDates)-1]
 
-	return GetSeason(lastEventDate, firstEventDate)
+	return GetSeason(lastEventDate, firstEventDate, months)
 }
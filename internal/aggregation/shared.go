@@ -1,26 +1,202 @@
 // Package aggregation provides data structures and types for event and player statistics aggregation.
 package aggregation
 
-func AggregateStats() error {
-	err := generateEventsList()
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"premodernonsdagar/internal/config"
+	"premodernonsdagar/internal/metrics"
+	"premodernonsdagar/internal/series"
+)
+
+var (
+	aggregations = metrics.NewCounter("premodern_aggregations_total",
+		"Aggregations of the stats, by whether they succeeded.", "result")
+	aggregationDuration = metrics.NewGauge("premodern_aggregation_duration_seconds",
+		"Time taken by the last aggregation of the stats.")
+)
+
+// aggregator is one aggregation of a series, with the directory the stats are written to and the player registry
+type aggregator struct {
+	series   series.Series
+	root     string
+	registry *PlayerRegistry
+}
+
+func (a *aggregator) inputPath(elem ...string) string {
+	return filepath.Join(append([]string{a.series.InputDir()}, elem...)...)
+}
+
+func (a *aggregator) outputPath(elem ...string) string {
+	return filepath.Join(append([]string{a.series.OutputDirIn(a.root)}, elem...)...)
+}
+
+// siteURL returns the URL of a page of the series being aggregated
+func (a *aggregator) siteURL(path string) string {
+	return a.series.URLPrefix() + path
+}
+
+type seriesEventFile struct {
+	Path   string
+	Series series.Series
+}
+
+// allEventFiles lists the input event files of every series
+func allEventFiles() ([]seriesEventFile, error) {
+	allSeries, err := series.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	eventFiles := []seriesEventFile{}
+	for _, s := range allSeries {
+		files, err := filepath.Glob(filepath.Join(s.InputDir(), "events", "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event files of series %s: %w", s.ID, err)
+		}
+		for _, file := range files {
+			eventFiles = append(eventFiles, seriesEventFile{Path: file, Series: s})
+		}
+	}
+	return eventFiles, nil
+}
+
+// AggregateStats aggregates every series on its own, so ratings and seasons are never mixed between them
+func AggregateStats(cfg config.Config) error {
+	_, err := AggregateStatsInto(cfg, series.OutputRoot)
+	return err
+}
+
+// AggregateStatsInto aggregates the stats into root instead of the served files, and returns the validation issues of the input events
+func AggregateStatsInto(cfg config.Config, root string) ([]string, error) {
+	started := time.Now()
+	issues, err := validateInputEvents()
+	if err == nil {
+		err = aggregateAllSeries(cfg, root)
+	}
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	aggregations.Inc(result)
+	aggregationDuration.Set(time.Since(started).Seconds())
+	return issues, err
+}
+
+func aggregateAllSeries(cfg config.Config, root string) error {
+	registry, err := LoadPlayerRegistry()
 	if err != nil {
 		return err
 	}
 
-	err = aggregatePlayerStats()
+	allSeries, err := series.Load()
 	if err != nil {
 		return err
 	}
 
-	err = generateLeaderboards()
+	for _, s := range allSeries {
+		a := &aggregator{series: s, root: root, registry: registry}
+		if err := a.aggregateSeries(cfg); err != nil {
+			return fmt.Errorf("failed to aggregate series %s: %w", s.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *aggregator) aggregateSeries(cfg config.Config) error {
+	err := a.generateEventsList()
+	if err != nil {
+		return err
+	}
+
+	err = a.aggregatePlayerStats(cfg.RatingMode)
 	if err != nil {
 		return err
 	}
 
-	err = generateDecklists()
+	err = a.generateLeaderboards()
+	if err != nil {
+		return err
+	}
+
+	err = a.generateDecklists()
+	if err != nil {
+		return err
+	}
+
+	err = a.generateExports()
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// validateInputEvents logs and returns any issues, and refuses to aggregate events with errors
+func validateInputEvents() ([]string, error) {
+	reports, err := ValidateEventFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(reports))
+	for path := range reports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	issues := []string{}
+	invalidFiles := 0
+	for _, path := range paths {
+		for _, issue := range reports[path].Issues {
+			slog.Warn("Event file issue", "path", path, "issue", issue)
+			issues = append(issues, fmt.Sprintf("%s: %s", path, issue))
+		}
+		if reports[path].HasErrors() {
+			invalidFiles++
+		}
+	}
+
+	if invalidFiles > 0 {
+		return issues, fmt.Errorf("%d event files failed validation", invalidFiles)
+	}
+	return issues, nil
+}
+
+// ValidateOutput checks that the stats built to root have the lists of every series and only valid JSON files
+func ValidateOutput(root string) error {
+	allSeries, err := series.Load()
+	if err != nil {
+		return err
+	}
+	for _, s := range allSeries {
+		for _, list := range []string{"events.json", "players.json"} {
+			if _, err := os.Stat(filepath.Join(s.OutputDirIn(root), "lists", list)); err != nil {
+				return fmt.Errorf("missing %s of series %s: %w", list, s.ID, err)
+			}
+		}
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !json.Valid(data) {
+			return fmt.Errorf("%s is not valid JSON", path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,43 @@
+package aggregation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"premodernonsdagar/internal/series"
+)
+
+// testAggregator aggregates the main series without registered players
+func testAggregator() *aggregator {
+	return &aggregator{series: series.Default(), root: series.OutputRoot, registry: NewPlayerRegistry(nil)}
+}
+
+func TestValidateOutput(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("lists/events.json", `{"events": []}`)
+	if err := ValidateOutput(root); err == nil {
+		t.Error("Expected a build without the players list to be invalid")
+	}
+
+	write("lists/players.json", `[]`)
+	write("events/2025-08-20.json", `{"name": "Onsdagstävling"}`)
+	if err := ValidateOutput(root); err != nil {
+		t.Errorf("Expected the build to be valid, got %v", err)
+	}
+
+	write("players/anna.json", `{"name": "Anna"`)
+	if err := ValidateOutput(root); err == nil {
+		t.Error("Expected a half written file to be invalid")
+	}
+}
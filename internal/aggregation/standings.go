@@ -0,0 +1,183 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultSeasonPointsRules is used when input/season_points.json does not exist
+var DefaultSeasonPointsRules = SeasonPointsRules{
+	ParticipationPoints: 3,
+	MatchWinPoints:      3,
+	MatchDrawPoints:     1,
+	CountedEvents:       8,
+	QualifiedPlayers:    8,
+}
+
+func (a *aggregator) loadSeasonPointsRules() (SeasonPointsRules, error) {
+	data, err := os.ReadFile(a.inputPath("season_points.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultSeasonPointsRules, nil
+	}
+	if err != nil {
+		return SeasonPointsRules{}, fmt.Errorf("failed to read season points rules: %w", err)
+	}
+
+	rules := DefaultSeasonPointsRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return SeasonPointsRules{}, fmt.Errorf("failed to parse season points rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// calculateSeasonStandings awards points per event and sums the best events for each player
+func (a *aggregator) calculateSeasonStandings(season string, eventsInSeason []Event, rules SeasonPointsRules) SeasonStandings {
+	eventPoints := make(map[string][]int)
+	matchesWon := make(map[string]int)
+	matchesDrawn := make(map[string]int)
+
+	for _, event := range eventsInSeason {
+		pointsInEvent := make(map[string]int)
+		for _, result := range event.Results {
+			pointsInEvent[result.Name] = rules.ParticipationPoints
+		}
+
+		for _, match := range event.Matches {
+			result := ParseMatchResult(match)
+
+			if result.Draw {
+				for _, name := range []string{match.Player1, match.Player2} {
+					if slices.Contains(match.ExtraMatch, name) {
+						continue
+					}
+					pointsInEvent[name] += rules.MatchDrawPoints
+					matchesDrawn[name]++
+				}
+				continue
+			}
+
+			if slices.Contains(match.ExtraMatch, result.Winner) {
+				continue
+			}
+			pointsInEvent[result.Winner] += rules.MatchWinPoints
+			matchesWon[result.Winner]++
+		}
+
+		for name, points := range pointsInEvent {
+			eventPoints[name] = append(eventPoints[name], points)
+		}
+	}
+
+	entries := make([]StandingsEntry, 0, len(eventPoints))
+	for name, points := range eventPoints {
+		sort.Sort(sort.Reverse(sort.IntSlice(points)))
+
+		counted := len(points)
+		if rules.CountedEvents > 0 && counted > rules.CountedEvents {
+			counted = rules.CountedEvents
+		}
+
+		entry := StandingsEntry{
+			Name:           name,
+			URL:            a.siteURL("/players/" + a.playerSlug(name)),
+			EventsCounted:  counted,
+			EventsAttended: len(points),
+			MatchesWon:     matchesWon[name],
+			MatchesDrawn:   matchesDrawn[name],
+		}
+		for i, p := range points {
+			if i < counted {
+				entry.Points += p
+			}
+			entry.TotalPoints += p
+		}
+
+		entries = append(entries, entry)
+	}
+
+	// Ties are broken by total points, then attendance, then name
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Points != entries[j].Points {
+			return entries[i].Points > entries[j].Points
+		}
+		if entries[i].TotalPoints != entries[j].TotalPoints {
+			return entries[i].TotalPoints > entries[j].TotalPoints
+		}
+		if entries[i].EventsAttended != entries[j].EventsAttended {
+			return entries[i].EventsAttended > entries[j].EventsAttended
+		}
+		return entries[i].Name < entries[j].Name
+	})
+
+	cutLine := min(rules.QualifiedPlayers, len(entries))
+	for i := range entries {
+		entries[i].Rank = i + 1
+		entries[i].Qualified = i < cutLine
+	}
+
+	return SeasonStandings{
+		Season:     strings.ToUpper(season),
+		Rules:      rules,
+		EventCount: len(eventsInSeason),
+		CutLine:    cutLine,
+		Entries:    entries,
+	}
+}
+
+// generateSeasonStandings writes the full standings for a season and returns the leaderboard version of it
+func (a *aggregator) generateSeasonStandings(season string, eventsInSeason []Event, rules SeasonPointsRules) (LeaderboardContainer, error) {
+	standings := a.calculateSeasonStandings(season, eventsInSeason, rules)
+
+	output, err := json.MarshalIndent(standings, "", "  ")
+	if err != nil {
+		return LeaderboardContainer{}, fmt.Errorf("failed to marshal standings for season %s: %w", season, err)
+	}
+
+	if err := os.WriteFile(a.outputPath("lists", "standings", season+".json"), output, 0644); err != nil {
+		return LeaderboardContainer{}, fmt.Errorf("failed to write standings for season %s: %w", season, err)
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(standings.Entries))
+	for _, entry := range standings.Entries {
+		entries = append(entries, LeaderboardEntry{
+			Name:      entry.Name,
+			Score:     entry.Points,
+			URL:       entry.URL,
+			Qualified: entry.Qualified,
+		})
+	}
+
+	return LeaderboardContainer{
+		Title:   "Season Points",
+		Entries: entries,
+		Type:    "standings",
+		CutLine: standings.CutLine,
+		URL:     a.siteURL("/seasons/" + season + "/standings"),
+	}, nil
+}
+
+func (a *aggregator) cleanupSeasonStandings(seasons []string) error {
+	standingsFiles, err := filepath.Glob(a.outputPath("lists", "standings", "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list standings files: %w", err)
+	}
+
+	for _, file := range standingsFiles {
+		season := strings.TrimSuffix(filepath.Base(file), ".json")
+		if slices.Contains(seasons, season) {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return fmt.Errorf("failed to remove old standings file %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package aggregation
+
+import (
+	"testing"
+)
+
+func TestCalculateSeasonStandings(t *testing.T) {
+	events := []Event{
+		{
+			Date:    "2025-09-02",
+			Results: []PlayerResult{{Name: "Alice"}, {Name: "Bob"}, {Name: "Carl"}},
+			Matches: []Match{
+				{Player1: "Alice", Player2: "Bob", Result: "2-0"},
+				{Player1: "Alice", Player2: "Carl", Result: "2-1"},
+				{Player1: "Bob", Player2: "Carl", Result: "1-1"},
+			},
+		},
+		{
+			Date:    "2025-09-16",
+			Results: []PlayerResult{{Name: "Alice"}, {Name: "Bob"}},
+			Matches: []Match{
+				{Player1: "Alice", Player2: "Bob", Result: "0-2"},
+			},
+		},
+		{
+			Date:    "2025-09-30",
+			Results: []PlayerResult{{Name: "Bob"}, {Name: "Carl"}},
+			Matches: []Match{
+				{Player1: "Bob", Player2: "Carl", Result: "0-2", ExtraMatch: []string{"Carl"}},
+			},
+		},
+	}
+
+	rules := SeasonPointsRules{
+		ParticipationPoints: 1,
+		MatchWinPoints:      3,
+		MatchDrawPoints:     1,
+		CountedEvents:       2,
+		QualifiedPlayers:    2,
+	}
+
+	a := testAggregator()
+	standings := a.calculateSeasonStandings("s01", events, rules)
+
+	if standings.Season != "S01" {
+		t.Errorf("Expected season S01, got %q", standings.Season)
+	}
+	if standings.CutLine != 2 {
+		t.Errorf("Expected cut line 2, got %d", standings.CutLine)
+	}
+
+	expected := []struct {
+		name        string
+		points      int
+		totalPoints int
+		qualified   bool
+	}{
+		{name: "Alice", points: 8, totalPoints: 8, qualified: true},
+		{name: "Bob", points: 6, totalPoints: 7, qualified: true},
+		{name: "Carl", points: 3, totalPoints: 3, qualified: false},
+	}
+
+	if len(standings.Entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(standings.Entries))
+	}
+
+	for i, want := range expected {
+		got := standings.Entries[i]
+		if got.Name != want.name {
+			t.Errorf("Rank %d: expected %s, got %s", i+1, want.name, got.Name)
+		}
+		if got.Points != want.points {
+			t.Errorf("%s: expected %d points, got %d", want.name, want.points, got.Points)
+		}
+		if got.TotalPoints != want.totalPoints {
+			t.Errorf("%s: expected %d total points, got %d", want.name, want.totalPoints, got.TotalPoints)
+		}
+		if got.Qualified != want.qualified {
+			t.Errorf("%s: expected qualified %v, got %v", want.name, want.qualified, got.Qualified)
+		}
+	}
+}
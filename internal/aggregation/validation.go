@@ -0,0 +1,198 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Best of three, so no player can win more than two games
+const maxGameWins = 2
+
+type ValidationIssue struct {
+	Severity string `json:"severity"`
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Field, i.Message)
+}
+
+type ValidationReport struct {
+	Issues []ValidationIssue `json:"issues"`
+}
+
+func (r *ValidationReport) addError(field, format string, args ...any) {
+	r.Issues = append(r.Issues, ValidationIssue{Severity: SeverityError, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ValidationReport) addWarning(field, format string, args ...any) {
+	r.Issues = append(r.Issues, ValidationIssue{Severity: SeverityWarning, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r ValidationReport) filter(severity string) []ValidationIssue {
+	issues := []ValidationIssue{}
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func (r ValidationReport) Errors() []ValidationIssue {
+	return r.filter(SeverityError)
+}
+
+func (r ValidationReport) Warnings() []ValidationIssue {
+	return r.filter(SeverityWarning)
+}
+
+func (r ValidationReport) HasErrors() bool {
+	return len(r.Errors()) > 0
+}
+
+// validResult reports whether a result is written as "<player 1 games>-<player 2 games>"
+func validResult(result string) bool {
+	var p1Games, p2Games int
+	if _, err := fmt.Sscanf(result, "%d-%d", &p1Games, &p2Games); err != nil || fmt.Sprintf("%d-%d", p1Games, p2Games) != result {
+		return false
+	}
+	return p1Games >= 0 && p2Games >= 0 && p1Games <= maxGameWins && p2Games <= maxGameWins && !(p1Games == maxGameWins && p2Games == maxGameWins)
+}
+
+// ValidateEvent checks an event for mistakes that would otherwise silently skew the stats
+func ValidateEvent(event InputEvent) ValidationReport {
+	report := ValidationReport{Issues: []ValidationIssue{}}
+
+	if strings.TrimSpace(event.Name) == "" {
+		report.addError("name", "event name is required")
+	}
+	if _, err := time.Parse("2006-01-02", event.Date); err != nil {
+		report.addError("date", "date %q is not formatted as YYYY-MM-DD", event.Date)
+	}
+	if event.Rounds <= 0 {
+		report.addError("rounds", "rounds must be at least 1, got %d", event.Rounds)
+	}
+	if len(event.Matches) == 0 {
+		report.addWarning("matches", "event has no matches")
+	}
+
+	pairs := make(map[string]int)
+	roundsPlayed := make(map[string]int)
+	missingInfo := make(map[string]bool)
+	for i, match := range event.Matches {
+		field := fmt.Sprintf("matches[%d]", i)
+
+		if strings.TrimSpace(match.Player1) == "" || strings.TrimSpace(match.Player2) == "" {
+			report.addError(field, "both players are required")
+			continue
+		}
+		if match.Player1 == match.Player2 {
+			report.addError(field, "%s cannot play against themselves", match.Player1)
+			continue
+		}
+
+		if match.Round < 0 || (event.Rounds > 0 && match.Round > event.Rounds) {
+			report.addError(field+".round", "round %d of %s vs %s is not one of the %d rounds", match.Round, match.Player1, match.Player2, event.Rounds)
+		}
+		if !validResult(match.Result) {
+			report.addError(field+".result", "result %q for %s vs %s is not a valid best of three result like 2-1", match.Result, match.Player1, match.Player2)
+		}
+
+		pair := []string{match.Player1, match.Player2}
+		sort.Strings(pair)
+		pairKey := strings.Join(pair, " vs ")
+		// Players can meet again in a later round, but only when the rounds are known
+		roundKey := fmt.Sprintf("%d %s", match.Round, pairKey)
+		if first, exists := pairs[roundKey]; exists {
+			if match.Round > 0 {
+				report.addError(field, "%s is entered twice in round %d, also in matches[%d]", pairKey, match.Round, first)
+			} else {
+				report.addError(field, "%s is entered twice, also in matches[%d]", pairKey, first)
+			}
+		} else {
+			pairs[roundKey] = i
+		}
+
+		for _, extra := range match.ExtraMatch {
+			if extra != match.Player1 && extra != match.Player2 {
+				report.addError(field+".extra_match", "%s did not play in %s vs %s", extra, match.Player1, match.Player2)
+			}
+		}
+
+		for _, player := range []string{match.Player1, match.Player2} {
+			if _, exists := event.PlayerInfo[player]; !exists && !missingInfo[player] {
+				missingInfo[player] = true
+				report.addWarning("player_info", "%s played in %s but has no player info", player, field)
+			}
+			if !slices.Contains(match.ExtraMatch, player) {
+				roundsPlayed[player]++
+			}
+		}
+	}
+
+	players := make([]string, 0, len(roundsPlayed))
+	for player := range roundsPlayed {
+		players = append(players, player)
+	}
+	sort.Strings(players)
+
+	for _, player := range players {
+		if event.Rounds > 0 && roundsPlayed[player] > event.Rounds {
+			report.addError("rounds", "%s played %d matches that are not marked as extra, but the event only had %d rounds", player, roundsPlayed[player], event.Rounds)
+		}
+	}
+
+	return report
+}
+
+// ValidateEventFile validates an event file, including that it is named after its date
+func ValidateEventFile(path string) (ValidationReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ValidationReport{}, fmt.Errorf("failed to read event file %s: %w", path, err)
+	}
+
+	var event InputEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		report := ValidationReport{}
+		report.addError("file", "invalid JSON: %v", err)
+		return report, nil
+	}
+
+	report := ValidateEvent(event)
+	if expected := event.Date + ".json"; filepath.Base(path) != expected {
+		report.addError("date", "file should be named %s to match the event date", expected)
+	}
+	return report, nil
+}
+
+// ValidateEventFiles validates the input event files of every series, keyed by path
+func ValidateEventFiles() (map[string]ValidationReport, error) {
+	eventFiles, err := allEventFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make(map[string]ValidationReport)
+	for _, eventFile := range eventFiles {
+		report, err := ValidateEventFile(eventFile.Path)
+		if err != nil {
+			return nil, err
+		}
+		reports[eventFile.Path] = report
+	}
+	return reports, nil
+}
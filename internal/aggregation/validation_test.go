@@ -0,0 +1,139 @@
+package aggregation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidResult(t *testing.T) {
+	valid := []string{"2-0", "2-1", "0-2", "1-2", "1-1", "0-0", "1-0"}
+	invalid := []string{"", "2", "3-0", "2-2", "-1-2", "2-1-0", "2:1", "two-one", "02-1", "2-1 "}
+
+	for _, result := range valid {
+		if !validResult(result) {
+			t.Errorf("Expected %q to be valid", result)
+		}
+	}
+	for _, result := range invalid {
+		if validResult(result) {
+			t.Errorf("Expected %q to be invalid", result)
+		}
+	}
+}
+
+func TestValidateEvent(t *testing.T) {
+	event := InputEvent{
+		Name:   "Onsdag",
+		Date:   "2025-08-19",
+		Rounds: 1,
+		PlayerInfo: map[string]PlayerEventInfo{
+			"Alice": {Deck: "Burn"},
+			"Bob":   {Deck: "Oath"},
+		},
+		Matches: []Match{
+			{Player1: "Alice", Player2: "Bob", Result: "2-1"},
+			{Player1: "Bob", Player2: "Alice", Result: "draw"},
+			{Player1: "Alice", Player2: "Carl", Result: "2-0", ExtraMatch: []string{"Dana"}},
+			{Player1: "Carl", Player2: "Carl", Result: "2-0"},
+		},
+	}
+
+	report := ValidateEvent(event)
+
+	expected := map[string]int{
+		"matches[1].result":      1,
+		"matches[1]":             1,
+		"matches[2].extra_match": 1,
+		"matches[3]":             1,
+		"rounds":                 2, // Alice played 3 matches, Bob 2
+	}
+	errors := make(map[string]int)
+	for _, issue := range report.Errors() {
+		errors[issue.Field]++
+	}
+	for field, count := range expected {
+		if errors[field] != count {
+			t.Errorf("Expected %d errors for %s, got %d: %v", count, field, errors[field], report.Errors())
+		}
+	}
+	if len(report.Errors()) != 6 {
+		t.Errorf("Expected 6 errors, got %v", report.Errors())
+	}
+
+	warnings := report.Warnings()
+	if len(warnings) != 1 || warnings[0].Field != "player_info" {
+		t.Errorf("Expected a single warning about Carl's player info, got %v", warnings)
+	}
+
+	if _, exists := event.PlayerInfo["Carl"]; exists {
+		t.Error("Expected validation not to modify the event")
+	}
+}
+
+func TestValidateEventValid(t *testing.T) {
+	event := InputEvent{
+		Name:   "Onsdag",
+		Date:   "2025-08-19",
+		Rounds: 1,
+		PlayerInfo: map[string]PlayerEventInfo{
+			"Alice": {Deck: "Burn"},
+			"Bob":   {Deck: "Oath"},
+			"Carl":  {Deck: "Goblins"},
+		},
+		Matches: []Match{
+			{Player1: "Alice", Player2: "Bob", Result: "2-1"},
+			{Player1: "Carl", Player2: "Alice", Result: "1-1", ExtraMatch: []string{"Alice", "Carl"}},
+		},
+	}
+
+	if report := ValidateEvent(event); len(report.Issues) != 0 {
+		t.Errorf("Expected no issues, got %v", report.Issues)
+	}
+}
+
+func TestValidateEventRematch(t *testing.T) {
+	event := InputEvent{
+		Name:   "Onsdag",
+		Date:   "2025-08-19",
+		Rounds: 3,
+		PlayerInfo: map[string]PlayerEventInfo{
+			"Alice": {Deck: "Burn"},
+			"Bob":   {Deck: "Oath"},
+		},
+		Matches: []Match{
+			{Player1: "Alice", Player2: "Bob", Result: "2-1", Round: 1},
+			{Player1: "Bob", Player2: "Alice", Result: "2-0", Round: 3},
+		},
+	}
+	if report := ValidateEvent(event); len(report.Issues) != 0 {
+		t.Errorf("Expected a rematch in a later round to be valid, got %v", report.Issues)
+	}
+
+	event.Matches = append(event.Matches, Match{Player1: "Alice", Player2: "Bob", Result: "0-2", Round: 4})
+	event.Matches[1].Round = 1
+	errors := make(map[string]int)
+	for _, issue := range ValidateEvent(event).Errors() {
+		errors[issue.Field]++
+	}
+	if errors["matches[1]"] != 1 || errors["matches[2].round"] != 1 {
+		t.Errorf("Expected errors for the repeat in round 1 and the match in round 4, got %v", errors)
+	}
+}
+
+func TestValidateEventFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "2025-08-20.json")
+	content := `{"name": "Onsdag", "date": "2025-08-19", "rounds": 4, "matches": [{"player_1": "Alice", "player_2": "Bob", "result": "2-0"}], "player_info": {"Alice": {}, "Bob": {}}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ValidateEventFile(path)
+	if err != nil {
+		t.Fatalf("ValidateEventFile returned error: %v", err)
+	}
+	if errors := report.Errors(); len(errors) != 1 || errors[0].Field != "date" {
+		t.Errorf("Expected the file name mismatch to be reported, got %v", report.Issues)
+	}
+}
@@ -0,0 +1,78 @@
+// Package assets serves the static files, with URLs holding the hash of the content that browsers cache for a year.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"premodernonsdagar"
+)
+
+// The static files are read from the binary, or from disk when developing, see UseDisk
+var (
+	files    fs.FS = mustSub(premodernonsdagar.Static, "static")
+	fromDisk bool
+	hashes   sync.Map
+)
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// UseDisk serves the static files from dir, so changes show without a restart
+func UseDisk(dir string) {
+	files = os.DirFS(dir)
+	fromDisk = true
+}
+
+// fingerprint returns the hash of the content of a static file, read once unless the files are on disk
+func fingerprint(name string) (string, error) {
+	if hash, ok := hashes.Load(name); ok && !fromDisk {
+		return hash.(string), nil
+	}
+
+	data, err := fs.ReadFile(files, name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:8])
+	if !fromDisk {
+		hashes.Store(name, hash)
+	}
+	return hash, nil
+}
+
+// Path returns the URL of a static file including the hash of its content, so a changed file gets a new URL
+func Path(name string) string {
+	hash, err := fingerprint(name)
+	if err != nil {
+		return "/static/" + name
+	}
+	return "/static/" + name + "?v=" + hash
+}
+
+// Handler serves the static files, with the /static/ prefix stripped. Files requested with the hash of their
+// content never change and are cached for a year, the others are revalidated with their ETag.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hash, err := fingerprint(strings.TrimPrefix(r.URL.Path, "/")); err == nil {
+			w.Header().Set("ETag", `"`+hash+`"`)
+			if r.URL.Query().Get("v") == hash {
+				w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			} else {
+				w.Header().Set("Cache-Control", "no-cache")
+			}
+		}
+		http.FileServerFS(files).ServeHTTP(w, r)
+	})
+}
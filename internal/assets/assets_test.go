@@ -0,0 +1,42 @@
+package assets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	path := Path("styles.css")
+	if !strings.HasPrefix(path, "/static/styles.css?v=") {
+		t.Fatalf("Expected the path to hold the hash, got %s", path)
+	}
+	if missing := Path("missing.css"); missing != "/static/missing.css" {
+		t.Errorf("Expected a missing file to keep its plain path, got %s", missing)
+	}
+
+	handler := http.StripPrefix("/static/", Handler())
+	get := func(url, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, url, nil)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get(path, "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Cache-Control"), "immutable") {
+		t.Errorf("Expected a hashed path to be cached for good, got %d and %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+
+	w = get("/static/styles.css", "")
+	if w.Header().Get("Cache-Control") != "no-cache" || w.Header().Get("ETag") == "" {
+		t.Errorf("Expected a plain path to be revalidated, got %q", w.Header().Get("Cache-Control"))
+	}
+	if w = get("/static/styles.css", w.Header().Get("ETag")); w.Code != http.StatusNotModified {
+		t.Errorf("Expected an unchanged file to not be sent again, got %d", w.Code)
+	}
+}
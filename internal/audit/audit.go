@@ -0,0 +1,270 @@
+// Package audit keeps an append-only log of changes to event files and a copy of every version they replace.
+// Every function takes the input directory of the series the event belongs to.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"premodernonsdagar/internal/aggregation"
+)
+
+const (
+	eventsDir  = "events"
+	historyDir = "history"
+	logFile    = "audit.jsonl"
+
+	versionFormat = "20060102T150405.000000000Z"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionRestore = "restore"
+	ActionImport  = "import"
+)
+
+var ErrVersionNotFound = errors.New("version not found")
+
+var mu sync.Mutex
+
+type Entry struct {
+	Time         time.Time `json:"time"`
+	User         string    `json:"user"`
+	Action       string    `json:"action"`
+	Date         string    `json:"date"`
+	PreviousDate string    `json:"previous_date,omitempty"` // Set when the event was moved to a new date
+	Version      string    `json:"version,omitempty"`       // The version that was replaced, empty for new events
+	Changes      []Change  `json:"changes"`
+}
+
+type Version struct {
+	ID    string
+	Time  time.Time
+	Event aggregation.InputEvent
+}
+
+func eventPath(dir, date string) string {
+	return filepath.Join(dir, eventsDir, date+".json")
+}
+
+func readEvent(path string) (*aggregation.InputEvent, []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var event aggregation.InputEvent
+	if err := json.Unmarshal(content, &event); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &event, content, nil
+}
+
+// snapshot copies the current content of an event file into the history before it is replaced
+func snapshot(dir, date string, content []byte, now time.Time) (string, error) {
+	versionsDir := filepath.Join(dir, historyDir, date)
+	if err := os.MkdirAll(versionsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	version := now.UTC().Format(versionFormat)
+	if err := os.WriteFile(filepath.Join(versionsDir, version+".json"), content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write version %s: %w", version, err)
+	}
+	return version, nil
+}
+
+func appendEntry(dir string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Join(dir, historyDir), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, historyDir, logFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// SaveEvent writes an event file, keeping a copy of the version it replaces and logging the changes.
+// previousDate is the date the event was stored under before, empty for new events.
+func SaveEvent(dir, user, action, previousDate string, event aggregation.InputEvent) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	entry := Entry{
+		Time:   now.UTC(),
+		User:   user,
+		Action: action,
+		Date:   event.Date,
+	}
+
+	if previousDate == "" {
+		previousDate = event.Date
+	}
+	if previousDate != event.Date {
+		entry.PreviousDate = previousDate
+	}
+
+	var previous *aggregation.InputEvent
+	old, content, err := readEvent(eventPath(dir, previousDate))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		previous = old
+		entry.Version, err = snapshot(dir, previousDate, content, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Moving an event must not overwrite another event without keeping a copy of it
+	if entry.PreviousDate != "" {
+		_, existing, err := readEvent(eventPath(dir, event.Date))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err == nil {
+			if _, err := snapshot(dir, event.Date, existing, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	entry.Changes = Diff(previous, &event)
+
+	eventJSON, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, eventsDir), 0755); err != nil {
+		return fmt.Errorf("failed to create events directory: %w", err)
+	}
+	if err := os.WriteFile(eventPath(dir, event.Date), eventJSON, 0644); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	if entry.PreviousDate != "" {
+		if err := os.Remove(eventPath(dir, entry.PreviousDate)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove old event file: %w", err)
+		}
+	}
+
+	return appendEntry(dir, entry)
+}
+
+// CurrentEvent returns the event stored for a date, or nil if there is none
+func CurrentEvent(dir, date string) (*aggregation.InputEvent, error) {
+	event, _, err := readEvent(eventPath(dir, date))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return event, err
+}
+
+// Versions returns the stored earlier versions of an event, newest first
+func Versions(dir, date string) ([]Version, error) {
+	files, err := os.ReadDir(filepath.Join(dir, historyDir, date))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Version{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	versions := []Version{}
+	for _, file := range files {
+		id, found := strings.CutSuffix(file.Name(), ".json")
+		if file.IsDir() || !found {
+			continue
+		}
+
+		versionTime, err := time.Parse(versionFormat, id)
+		if err != nil {
+			continue
+		}
+
+		event, _, err := readEvent(filepath.Join(dir, historyDir, date, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, Version{ID: id, Time: versionTime, Event: *event})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+
+	return versions, nil
+}
+
+// FindVersion returns a single stored version of an event
+func FindVersion(dir, date, id string) (Version, error) {
+	versions, err := Versions(dir, date)
+	if err != nil {
+		return Version{}, err
+	}
+
+	for _, version := range versions {
+		if version.ID == id {
+			return version, nil
+		}
+	}
+	return Version{}, ErrVersionNotFound
+}
+
+// Entries returns the logged changes that touched an event date, newest first
+func Entries(dir, date string) ([]Entry, error) {
+	file, err := os.Open(filepath.Join(dir, historyDir, logFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log: %w", err)
+		}
+		if entry.Date == date || entry.PreviousDate == date {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	return entries, nil
+}
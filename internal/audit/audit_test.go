@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"os"
+	"testing"
+
+	"premodernonsdagar/internal/aggregation"
+)
+
+func TestSaveEventKeepsHistory(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	event := aggregation.InputEvent{
+		Name:    "Onsdag",
+		Date:    "2025-08-19",
+		Matches: []aggregation.Match{{Player1: "Alice", Player2: "Bob", Result: "2-0"}},
+	}
+	if err := SaveEvent("input", "alice", ActionCreate, "", event); err != nil {
+		t.Fatalf("SaveEvent returned error: %v", err)
+	}
+
+	event.Matches[0].Result = "0-2"
+	event.Date = "2025-08-20"
+	if err := SaveEvent("input", "bob", ActionUpdate, "2025-08-19", event); err != nil {
+		t.Fatalf("SaveEvent returned error: %v", err)
+	}
+
+	if _, err := os.Stat("input/events/2025-08-19.json"); !os.IsNotExist(err) {
+		t.Error("Expected the old event file to be moved")
+	}
+
+	versions, err := Versions("input", "2025-08-19")
+	if err != nil {
+		t.Fatalf("Versions returned error: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Event.Matches[0].Result != "2-0" {
+		t.Fatalf("Expected the original version to be kept, got %+v", versions)
+	}
+
+	entries, err := Entries("input", "2025-08-19")
+	if err != nil {
+		t.Fatalf("Entries returned error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(entries))
+	}
+	if entries[0].User != "bob" || entries[0].PreviousDate != "2025-08-19" || entries[0].Version != versions[0].ID {
+		t.Errorf("Unexpected latest entry %+v", entries[0])
+	}
+	if entries[1].Action != ActionCreate || entries[1].Version != "" {
+		t.Errorf("Unexpected first entry %+v", entries[1])
+	}
+
+	if err := SaveEvent("input", "alice", ActionRestore, "", versions[0].Event); err != nil {
+		t.Fatalf("SaveEvent returned error: %v", err)
+	}
+	restored, err := CurrentEvent("input", "2025-08-19")
+	if err != nil || restored == nil || restored.Matches[0].Result != "2-0" {
+		t.Errorf("Expected the original version to be restored, got %+v, %v", restored, err)
+	}
+
+	if _, err := FindVersion("input", "2025-08-19", "../../go"); err != ErrVersionNotFound {
+		t.Errorf("Expected unknown versions to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"premodernonsdagar/internal/aggregation"
+)
+
+const (
+	ChangeName          = "name"
+	ChangeDate          = "date"
+	ChangeRounds        = "rounds"
+	ChangeMatchAdded    = "match_added"
+	ChangeMatchRemoved  = "match_removed"
+	ChangeResult        = "result"
+	ChangeDeck          = "deck"
+	ChangeDecklist      = "decklist"
+	ChangePlayerAdded   = "player_added"
+	ChangePlayerRemoved = "player_removed"
+)
+
+type Change struct {
+	Type    string `json:"type"`
+	Subject string `json:"subject,omitempty"` // The match or player the change is about
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	subject := c.Subject
+	if subject != "" {
+		subject = " " + subject
+	}
+
+	switch {
+	case c.Old == "" && c.New == "":
+		return fmt.Sprintf("%s%s", c.Type, subject)
+	case c.Old == "":
+		return fmt.Sprintf("%s%s: %s", c.Type, subject, c.New)
+	case c.New == "":
+		return fmt.Sprintf("%s%s: %s", c.Type, subject, c.Old)
+	}
+	return fmt.Sprintf("%s%s: %s → %s", c.Type, subject, c.Old, c.New)
+}
+
+type matchKey struct {
+	player1 string
+	player2 string
+}
+
+// normalizeMatch orders the players alphabetically so a match entered from either side of the grid compares equal
+func normalizeMatch(match aggregation.Match) (matchKey, string) {
+	if match.Player1 <= match.Player2 {
+		return matchKey{match.Player1, match.Player2}, match.Result
+	}
+
+	result := match.Result
+	if first, second, found := strings.Cut(result, "-"); found {
+		result = second + "-" + first
+	}
+	return matchKey{match.Player2, match.Player1}, result
+}
+
+func matchResults(event *aggregation.InputEvent) (map[matchKey]string, []matchKey) {
+	results := make(map[matchKey]string)
+	keys := []matchKey{}
+	if event == nil {
+		return results, keys
+	}
+
+	for _, match := range event.Matches {
+		key, result := normalizeMatch(match)
+		if _, exists := results[key]; !exists {
+			keys = append(keys, key)
+		}
+		results[key] = result
+	}
+	return results, keys
+}
+
+func sortedPlayers(infos ...map[string]aggregation.PlayerEventInfo) []string {
+	seen := make(map[string]bool)
+	players := []string{}
+	for _, info := range infos {
+		for player := range info {
+			if !seen[player] {
+				seen[player] = true
+				players = append(players, player)
+			}
+		}
+	}
+	sort.Strings(players)
+	return players
+}
+
+// Diff lists the changes between two versions of an event, previous is nil for new events
+func Diff(previous, current *aggregation.InputEvent) []Change {
+	changes := []Change{}
+	if previous == nil {
+		previous = &aggregation.InputEvent{}
+	}
+
+	if previous.Name != current.Name {
+		changes = append(changes, Change{Type: ChangeName, Old: previous.Name, New: current.Name})
+	}
+	if previous.Date != current.Date {
+		changes = append(changes, Change{Type: ChangeDate, Old: previous.Date, New: current.Date})
+	}
+	if previous.Rounds != current.Rounds {
+		changes = append(changes, Change{Type: ChangeRounds, Old: fmt.Sprint(previous.Rounds), New: fmt.Sprint(current.Rounds)})
+	}
+
+	previousResults, previousKeys := matchResults(previous)
+	currentResults, currentKeys := matchResults(current)
+
+	for _, key := range previousKeys {
+		subject := key.player1 + " vs " + key.player2
+		result, exists := currentResults[key]
+		if !exists {
+			changes = append(changes, Change{Type: ChangeMatchRemoved, Subject: subject, Old: previousResults[key]})
+			continue
+		}
+		if result != previousResults[key] {
+			changes = append(changes, Change{Type: ChangeResult, Subject: subject, Old: previousResults[key], New: result})
+		}
+	}
+	for _, key := range currentKeys {
+		if _, exists := previousResults[key]; !exists {
+			changes = append(changes, Change{Type: ChangeMatchAdded, Subject: key.player1 + " vs " + key.player2, New: currentResults[key]})
+		}
+	}
+
+	for _, player := range sortedPlayers(previous.PlayerInfo, current.PlayerInfo) {
+		before, existedBefore := previous.PlayerInfo[player]
+		after, existsNow := current.PlayerInfo[player]
+
+		switch {
+		case !existedBefore:
+			changes = append(changes, Change{Type: ChangePlayerAdded, Subject: player, New: after.Deck})
+		case !existsNow:
+			changes = append(changes, Change{Type: ChangePlayerRemoved, Subject: player, Old: before.Deck})
+		default:
+			if before.Deck != after.Deck {
+				changes = append(changes, Change{Type: ChangeDeck, Subject: player, Old: before.Deck, New: after.Deck})
+			}
+			if before.Decklist != after.Decklist {
+				changes = append(changes, Change{Type: ChangeDecklist, Subject: player, Old: before.Decklist, New: after.Decklist})
+			}
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	"premodernonsdagar/internal/aggregation"
+)
+
+func TestDiff(t *testing.T) {
+	previous := &aggregation.InputEvent{
+		Name:   "Onsdag",
+		Date:   "2025-08-19",
+		Rounds: 4,
+		PlayerInfo: map[string]aggregation.PlayerEventInfo{
+			"Alice": {Deck: "Burn"},
+			"Bob":   {Deck: "Stiflenought"},
+			"Carl":  {Deck: "Goblins"},
+		},
+		Matches: []aggregation.Match{
+			{Player1: "Alice", Player2: "Bob", Result: "2-0"},
+			{Player1: "Bob", Player2: "Carl", Result: "2-1"},
+		},
+	}
+	current := &aggregation.InputEvent{
+		Name:   "Onsdag",
+		Date:   "2025-08-20",
+		Rounds: 4,
+		PlayerInfo: map[string]aggregation.PlayerEventInfo{
+			"Alice": {Deck: "Burn"},
+			"Bob":   {Deck: "Oath"},
+			"Dana":  {Deck: "Elves"},
+		},
+		Matches: []aggregation.Match{
+			{Player1: "Bob", Player2: "Alice", Result: "1-2"},
+			{Player1: "Bob", Player2: "Dana", Result: "0-2"},
+		},
+	}
+
+	expected := []Change{
+		{Type: ChangeDate, Old: "2025-08-19", New: "2025-08-20"},
+		{Type: ChangeResult, Subject: "Alice vs Bob", Old: "2-0", New: "2-1"},
+		{Type: ChangeMatchRemoved, Subject: "Bob vs Carl", Old: "2-1"},
+		{Type: ChangeMatchAdded, Subject: "Bob vs Dana", New: "0-2"},
+		{Type: ChangeDeck, Subject: "Bob", Old: "Stiflenought", New: "Oath"},
+		{Type: ChangePlayerRemoved, Subject: "Carl", Old: "Goblins"},
+		{Type: ChangePlayerAdded, Subject: "Dana", New: "Elves"},
+	}
+
+	changes := Diff(previous, current)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Diff() = %+v, want %+v", changes, expected)
+	}
+
+	if len(Diff(current, current)) != 0 {
+		t.Error("Expected no changes between identical events")
+	}
+}
+
+func TestDiffNewEvent(t *testing.T) {
+	current := &aggregation.InputEvent{
+		Name:    "Onsdag",
+		Date:    "2025-08-19",
+		Matches: []aggregation.Match{{Player1: "Alice", Player2: "Bob", Result: "2-0"}},
+	}
+
+	changes := Diff(nil, current)
+	if len(changes) != 3 || changes[2].Type != ChangeMatchAdded {
+		t.Errorf("Unexpected changes for a new event: %+v", changes)
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	change := Change{Type: ChangeResult, Subject: "Alice vs Bob", Old: "2-0", New: "2-1"}
+	if change.String() != "result Alice vs Bob: 2-0 → 2-1" {
+		t.Errorf("Unexpected change description %q", change.String())
+	}
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword returned error: %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "pbkdf2-sha256$600000$") {
+		t.Errorf("Unexpected hash format %q", hash)
+	}
+	if !VerifyPassword(hash, "correct horse") {
+		t.Error("Expected the password to match its hash")
+	}
+	if VerifyPassword(hash, "battery staple") {
+		t.Error("Expected a different password not to match")
+	}
+
+	other, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword returned error: %v", err)
+	}
+	if other == hash {
+		t.Error("Expected hashes of the same password to use different salts")
+	}
+}
+
+func TestVerifyPasswordInvalidHash(t *testing.T) {
+	for _, hash := range []string{"", "plaintext", "bcrypt$10$salt$key", "pbkdf2-sha256$abc$c2FsdA$a2V5", "pbkdf2-sha256$1000$!!$a2V5"} {
+		if VerifyPassword(hash, "plaintext") {
+			t.Errorf("Expected %q not to verify", hash)
+		}
+	}
+}
+
+func TestParseUsers(t *testing.T) {
+	users, err := ParseUsers(" alice:hash1, bob:pbkdf2-sha256$1$a$b ,")
+	if err != nil {
+		t.Fatalf("ParseUsers returned error: %v", err)
+	}
+	if len(users) != 2 || users["alice"] != "hash1" || users["bob"] != "pbkdf2-sha256$1$a$b" {
+		t.Errorf("Unexpected users %v", users)
+	}
+
+	if _, err := ParseUsers("alice"); err == nil {
+		t.Error("Expected an entry without a hash to be rejected")
+	}
+}
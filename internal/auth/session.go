@@ -0,0 +1,424 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sessionCookieName   = "admin_session"
+	loginCSRFCookieName = "admin_login_csrf"
+	sessionTTL          = 12 * time.Hour
+	loginCSRFTTL        = time.Hour
+	developmentUser     = "dev"
+
+	// Logins from an address or to a user are refused for the rest of the window after this many failures
+	maxLoginFailures   = 5
+	loginFailureWindow = 15 * time.Minute
+)
+
+var ErrInvalidCredentials = errors.New("invalid username or password")
+var ErrInvalidCSRFToken = errors.New("invalid or missing CSRF token")
+var ErrTooManyAttempts = errors.New("too many failed logins")
+
+type contextKey struct{}
+
+type loginFailures struct {
+	count int
+	since time.Time
+}
+
+type Session struct {
+	ID        string
+	Username  string
+	CSRFToken string
+	Expires   time.Time
+}
+
+// Authenticator keeps admin sessions in memory, so a restart logs everyone out
+type Authenticator struct {
+	users         map[string]string // username -> password hash
+	open          bool
+	secureCookies bool
+	csrfKey       []byte         // Signs the CSRF tokens of the login form, which is shown without a session
+	proxies       []netip.Prefix // Reverse proxies trusted to name the client in X-Forwarded-For
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	failures map[string]*loginFailures // "user:<name>" or "ip:<address>" -> failed logins in the current window
+}
+
+// NewAuthenticator requires logging in as one of the given users
+func NewAuthenticator(users map[string]string, secureCookies bool) *Authenticator {
+	return &Authenticator{
+		users:         users,
+		secureCookies: secureCookies,
+		csrfKey:       []byte(rand.Text()),
+		sessions:      make(map[string]*Session),
+		failures:      make(map[string]*loginFailures),
+	}
+}
+
+// TrustProxies counts failed logins for the client named in X-Forwarded-For when the request comes through one of the
+// proxies, instead of for the proxy itself
+func (a *Authenticator) TrustProxies(proxies []netip.Prefix) {
+	a.proxies = proxies
+}
+
+// NewOpenAuthenticator logs every visitor in automatically, it is only meant for local development
+func NewOpenAuthenticator() *Authenticator {
+	return &Authenticator{
+		open:     true,
+		csrfKey:  []byte(rand.Text()),
+		sessions: make(map[string]*Session),
+		failures: make(map[string]*loginFailures),
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (a *Authenticator) session(r *http.Request) *Session {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	session, exists := a.sessions[cookie.Value]
+	if !exists {
+		return nil
+	}
+	if time.Now().After(session.Expires) {
+		delete(a.sessions, cookie.Value)
+		return nil
+	}
+	return session
+}
+
+func (a *Authenticator) startSession(w http.ResponseWriter, username string) (*Session, error) {
+	id, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	csrfToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{
+		ID:        id,
+		Username:  username,
+		CSRFToken: csrfToken,
+		Expires:   time.Now().Add(sessionTTL),
+	}
+
+	a.mu.Lock()
+	for key, existing := range a.sessions {
+		if time.Now().After(existing.Expires) {
+			delete(a.sessions, key)
+		}
+	}
+	a.sessions[id] = session
+	a.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    id,
+		Path:     "/admin",
+		Expires:  session.Expires,
+		HttpOnly: true,
+		Secure:   a.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return session, nil
+}
+
+func (a *Authenticator) endSession(w http.ResponseWriter, session *Session) {
+	a.mu.Lock()
+	delete(a.sessions, session.ID)
+	a.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/admin",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func validCSRFToken(r *http.Request, session *Session) bool {
+	token := r.FormValue("csrf_token")
+	if token == "" {
+		token = r.Header.Get("X-CSRF-Token")
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(session.CSRFToken)) == 1
+}
+
+// Require only lets logged in sessions through, and checks the CSRF token on anything but GET and HEAD requests
+func (a *Authenticator) Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := a.session(r)
+
+		if a.open && session == nil {
+			var err error
+			session, err = a.startSession(w, developmentUser)
+			if err != nil {
+				http.Error(w, "Error creating session", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if session == nil {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				http.Error(w, "Login required", http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, r, "/admin/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !validCSRFToken(r, session) {
+			http.Error(w, ErrInvalidCSRFToken.Error(), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, session)))
+	})
+}
+
+// Open reports whether every visitor is logged in automatically
+func (a *Authenticator) Open() bool {
+	return a.open
+}
+
+// LoginCSRFToken returns the CSRF token for the login form. It is kept in a cookie and signed instead of in a session,
+// so showing the login page does not store anything.
+func (a *Authenticator) LoginCSRFToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	if cookie, err := r.Cookie(loginCSRFCookieName); err == nil && a.validLoginCSRFToken(cookie.Value) {
+		return cookie.Value, nil
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	token := nonce + "." + a.signLoginCSRFToken(nonce)
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCSRFCookieName,
+		Value:    token,
+		Path:     "/admin/login",
+		MaxAge:   int(loginCSRFTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   a.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return token, nil
+}
+
+func (a *Authenticator) signLoginCSRFToken(nonce string) string {
+	mac := hmac.New(sha256.New, a.csrfKey)
+	mac.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (a *Authenticator) validLoginCSRFToken(token string) bool {
+	nonce, signature, found := strings.Cut(token, ".")
+	return found && hmac.Equal([]byte(signature), []byte(a.signLoginCSRFToken(nonce)))
+}
+
+// ClientAddress is the address failed logins are counted for. Behind trusted proxies it is the last address in
+// X-Forwarded-For that was not added by one of them, the addresses before it are whatever the client sent.
+func (a *Authenticator) ClientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !a.trustedProxy(host) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(forwarded[i])
+		if address == "" {
+			continue
+		}
+		if !a.trustedProxy(address) {
+			return address
+		}
+		host = address
+	}
+	return host
+}
+
+func (a *Authenticator) trustedProxy(address string) bool {
+	ip, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, proxy := range a.proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProxies parses addresses and CIDR ranges separated by commas
+func ParseProxies(value string) ([]netip.Prefix, error) {
+	proxies := []netip.Prefix{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		ip = ip.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return proxies, nil
+}
+
+// loginBlocked reports whether any of the keys has failed too many logins in the current window
+func (a *Authenticator) loginBlocked(keys []string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, key := range keys {
+		failures, exists := a.failures[key]
+		if exists && time.Since(failures.since) < loginFailureWindow && failures.count >= maxLoginFailures {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authenticator) recordLoginFailure(keys []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for key, failures := range a.failures {
+		if time.Since(failures.since) >= loginFailureWindow {
+			delete(a.failures, key)
+		}
+	}
+	for _, key := range keys {
+		failures, exists := a.failures[key]
+		if !exists {
+			failures = &loginFailures{since: time.Now()}
+			a.failures[key] = failures
+		}
+		failures.count++
+	}
+}
+
+func (a *Authenticator) clearLoginFailures(keys []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, key := range keys {
+		delete(a.failures, key)
+	}
+}
+
+// Login checks the CSRF token of the login form and the credentials, and starts a logged in session.
+// Logins from an address, or to a user, that failed too many times recently are refused without checking the password.
+func (a *Authenticator) Login(w http.ResponseWriter, r *http.Request, username, password string) error {
+	cookie, err := r.Cookie(loginCSRFCookieName)
+	token := r.FormValue("csrf_token")
+	if err != nil || token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 ||
+		!a.validLoginCSRFToken(token) {
+		return ErrInvalidCSRFToken
+	}
+
+	keys := []string{"ip:" + a.ClientAddress(r)}
+	hash, exists := a.users[username]
+	if exists {
+		// Only known users are counted, so guessing names does not fill the map
+		keys = append(keys, "user:"+username)
+	}
+	if a.loginBlocked(keys) {
+		return ErrTooManyAttempts
+	}
+	if !exists || !VerifyPassword(hash, password) {
+		a.recordLoginFailure(keys)
+		return ErrInvalidCredentials
+	}
+	a.clearLoginFailures(keys)
+
+	if session := a.session(r); session != nil {
+		a.endSession(w, session)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCSRFCookieName,
+		Value:    "",
+		Path:     "/admin/login",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+	_, err = a.startSession(w, username)
+	return err
+}
+
+// Logout ends the session of a request that has passed through Require
+func (a *Authenticator) Logout(w http.ResponseWriter, r *http.Request) {
+	if session, ok := r.Context().Value(contextKey{}).(*Session); ok {
+		a.endSession(w, session)
+	}
+}
+
+// CSRFToken returns the token to include as "csrf_token" in forms, for requests that have passed through Require
+func CSRFToken(r *http.Request) string {
+	if session, ok := r.Context().Value(contextKey{}).(*Session); ok {
+		return session.CSRFToken
+	}
+	return ""
+}
+
+// Username returns the logged in user, for requests that have passed through Require
+func Username(r *http.Request) string {
+	if session, ok := r.Context().Value(contextKey{}).(*Session); ok {
+		return session.Username
+	}
+	return ""
+}
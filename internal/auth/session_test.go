@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword returned error: %v", err)
+	}
+	return NewAuthenticator(map[string]string{"alice": hash}, true)
+}
+
+func postForm(target string, values url.Values, cookies []*http.Cookie) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+func TestRequireRedirectsToLogin(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	handler := authenticator.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected the handler not to be called")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/events", nil))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin/login?next=%2Fadmin%2Fevents" {
+		t.Errorf("Expected redirect to login, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm("/admin/events/new", url.Values{}, nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an anonymous POST, got %d", w.Code)
+	}
+}
+
+func TestLoginAndCSRF(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	w := httptest.NewRecorder()
+	loginToken, err := authenticator.LoginCSRFToken(w, httptest.NewRequest(http.MethodGet, "/admin/login", nil))
+	if err != nil {
+		t.Fatalf("LoginCSRFToken returned error: %v", err)
+	}
+	cookies := w.Result().Cookies()
+	if len(authenticator.sessions) != 0 {
+		t.Error("Expected the login page not to start a session")
+	}
+
+	err = authenticator.Login(httptest.NewRecorder(), postForm("/admin/login", url.Values{"username": {"alice"}, "password": {"secret"}}, cookies), "alice", "secret")
+	if err != ErrInvalidCSRFToken {
+		t.Errorf("Expected login without CSRF token to fail, got %v", err)
+	}
+
+	forged := url.Values{"csrf_token": {"forged.token"}}
+	err = authenticator.Login(httptest.NewRecorder(), postForm("/admin/login", forged, []*http.Cookie{{Name: loginCSRFCookieName, Value: "forged.token"}}), "alice", "secret")
+	if err != ErrInvalidCSRFToken {
+		t.Errorf("Expected login with an unsigned CSRF token to fail, got %v", err)
+	}
+
+	form := url.Values{"csrf_token": {loginToken}}
+	err = authenticator.Login(httptest.NewRecorder(), postForm("/admin/login", form, cookies), "alice", "wrong")
+	if err != ErrInvalidCredentials {
+		t.Errorf("Expected login with wrong password to fail, got %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	if err := authenticator.Login(w, postForm("/admin/login", form, cookies), "alice", "secret"); err != nil {
+		t.Fatalf("Expected login to succeed, got %v", err)
+	}
+	loggedIn := w.Result().Cookies()
+	if len(loggedIn) < 2 || loggedIn[len(loggedIn)-1].Name != sessionCookieName {
+		t.Fatal("Expected a session to be started on login")
+	}
+	sessionCookie := loggedIn[len(loggedIn)-1]
+	if !sessionCookie.HttpOnly || !sessionCookie.Secure {
+		t.Error("Expected the session cookie to be HttpOnly and Secure")
+	}
+
+	var csrfToken, username string
+	handler := authenticator.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		csrfToken, username = CSRFToken(r), Username(r)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/admin/events", nil)
+	r.AddCookie(sessionCookie)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || username != "alice" || csrfToken == "" || csrfToken == loginToken {
+		t.Fatalf("Expected a logged in request with a new CSRF token, got %d %q", w.Code, username)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm("/admin/events/new", url.Values{"csrf_token": {"forged"}}, []*http.Cookie{sessionCookie}))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a forged CSRF token, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm("/admin/events/new", url.Values{"csrf_token": {csrfToken}}, []*http.Cookie{sessionCookie}))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected POST with a valid CSRF token to pass, got %d", w.Code)
+	}
+}
+
+func TestLoginFailureLimit(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	w := httptest.NewRecorder()
+	loginToken, err := authenticator.LoginCSRFToken(w, httptest.NewRequest(http.MethodGet, "/admin/login", nil))
+	if err != nil {
+		t.Fatalf("LoginCSRFToken returned error: %v", err)
+	}
+	cookies := w.Result().Cookies()
+	login := func(remoteAddr, username, password string) error {
+		r := postForm("/admin/login", url.Values{"csrf_token": {loginToken}}, cookies)
+		r.RemoteAddr = remoteAddr
+		return authenticator.Login(httptest.NewRecorder(), r, username, password)
+	}
+
+	for range maxLoginFailures {
+		if err := login("192.0.2.1:1234", "alice", "wrong"); err != ErrInvalidCredentials {
+			t.Fatalf("Expected login with wrong password to fail, got %v", err)
+		}
+	}
+	if err := login("192.0.2.1:1234", "bob", "secret"); err != ErrTooManyAttempts {
+		t.Errorf("Expected logins from the address to be refused, got %v", err)
+	}
+	if err := login("198.51.100.1:1234", "alice", "secret"); err != ErrTooManyAttempts {
+		t.Errorf("Expected logins to the user to be refused from other addresses, got %v", err)
+	}
+
+	for _, failures := range authenticator.failures {
+		failures.since = failures.since.Add(-loginFailureWindow)
+	}
+	if err := login("192.0.2.1:1234", "alice", "secret"); err != nil {
+		t.Errorf("Expected login to succeed after the window, got %v", err)
+	}
+	if len(authenticator.failures) != 0 {
+		t.Errorf("Expected the failures to be cleared, got %d", len(authenticator.failures))
+	}
+}
+
+func TestClientAddress(t *testing.T) {
+	proxies, err := ParseProxies("10.0.0.0/8, 192.0.2.10")
+	if err != nil {
+		t.Fatalf("ParseProxies returned error: %v", err)
+	}
+	authenticator := newTestAuthenticator(t)
+	authenticator.TrustProxies(proxies)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		{"direct", "198.51.100.7:1234", nil, "198.51.100.7"},
+		{"untrusted proxy", "198.51.100.7:1234", []string{"203.0.113.5"}, "198.51.100.7"},
+		{"trusted proxy", "10.1.2.3:1234", []string{"203.0.113.5"}, "203.0.113.5"},
+		{"spoofed header", "10.1.2.3:1234", []string{"1.2.3.4, 203.0.113.5"}, "203.0.113.5"},
+		{"chain of proxies", "10.1.2.3:1234", []string{"203.0.113.5", "192.0.2.10"}, "203.0.113.5"},
+		{"no header", "192.0.2.10:1234", nil, "192.0.2.10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/admin/login", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if address := authenticator.ClientAddress(r); address != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, address)
+			}
+		})
+	}
+
+	if _, err := ParseProxies("10.0.0.0/33"); err == nil {
+		t.Error("Expected an error for an invalid range")
+	}
+}
+
+func TestOpenAuthenticator(t *testing.T) {
+	authenticator := NewOpenAuthenticator()
+
+	var username string
+	handler := authenticator.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username = Username(r)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/events", nil))
+	if w.Code != http.StatusOK || username != developmentUser {
+		t.Errorf("Expected development user to be logged in automatically, got %d %q", w.Code, username)
+	}
+}
@@ -0,0 +1,129 @@
+// Package calendar writes iCalendar feeds that calendar apps can subscribe to.
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	timezone   = "Europe/Stockholm"
+	lineLength = 75
+)
+
+// The rules of the Swedish time zone, so the feed does not depend on the time zones known by the app
+var vtimezone = []string{
+	"BEGIN:VTIMEZONE",
+	"TZID:" + timezone,
+	"BEGIN:STANDARD",
+	"DTSTART:19701025T030000",
+	"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU",
+	"TZOFFSETFROM:+0200",
+	"TZOFFSETTO:+0100",
+	"TZNAME:CET",
+	"END:STANDARD",
+	"BEGIN:DAYLIGHT",
+	"DTSTART:19700329T020000",
+	"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU",
+	"TZOFFSETFROM:+0100",
+	"TZOFFSETTO:+0200",
+	"TZNAME:CEST",
+	"END:DAYLIGHT",
+	"END:VTIMEZONE",
+}
+
+type Event struct {
+	UID         string // Kept when the event changes, so apps update it rather than adding a new one
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time // Wall clock time in Stockholm
+	End         time.Time
+	AllDay      bool // Only the dates of Start and End are used
+	Cancelled   bool
+}
+
+// escape escapes the characters with a meaning in text values
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// fold splits lines longer than 75 bytes, without splitting a character, continuing them on lines starting with a space
+func fold(line string) string {
+	var folded strings.Builder
+	limit := lineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		folded.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = lineLength - 1
+	}
+	folded.WriteString(line)
+	return folded.String()
+}
+
+func eventLines(e Event, stamp string) []string {
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + e.UID,
+		"DTSTAMP:" + stamp,
+	}
+	if e.AllDay {
+		lines = append(lines,
+			"DTSTART;VALUE=DATE:"+e.Start.Format("20060102"),
+			"DTEND;VALUE=DATE:"+e.End.Format("20060102"))
+	} else {
+		lines = append(lines,
+			"DTSTART;TZID="+timezone+":"+e.Start.Format("20060102T150405"),
+			"DTEND;TZID="+timezone+":"+e.End.Format("20060102T150405"))
+	}
+
+	lines = append(lines, "SUMMARY:"+escape(e.Summary))
+	if e.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escape(e.Description))
+	}
+	if e.Location != "" {
+		lines = append(lines, "LOCATION:"+escape(e.Location))
+	}
+	if e.Cancelled {
+		lines = append(lines, "STATUS:CANCELLED")
+	} else {
+		lines = append(lines, "STATUS:CONFIRMED")
+	}
+	return append(lines, "END:VEVENT")
+}
+
+// Write writes the events as a calendar with the name
+func Write(w io.Writer, name string, events []Event) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Premodern Onsdagar//Calendar//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escape(name),
+		"X-WR-TIMEZONE:" + timezone,
+	}
+	lines = append(lines, vtimezone...)
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, e := range events {
+		lines = append(lines, eventLines(e, stamp)...)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var calendar strings.Builder
+	for _, line := range lines {
+		calendar.WriteString(fold(line) + "\r\n")
+	}
+	if _, err := io.WriteString(w, calendar.String()); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	err := Write(&b, "Premodern Onsdagar", []Event{
+		{
+			UID:      "onsdagar-2025-10-01@premodernonsdagar",
+			Summary:  "Premodern Onsdagar",
+			Location: "Biljardpalatset, Fridhemsplan",
+			Start:    time.Date(2025, 10, 1, 17, 0, 0, 0, time.UTC),
+			End:      time.Date(2025, 10, 1, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			UID:         "onsdagar-2025-12-24@premodernonsdagar",
+			Summary:     "Premodern Onsdagar",
+			Description: "Christmas; no event",
+			Start:       time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC),
+			End:         time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC),
+			AllDay:      true,
+			Cancelled:   true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	calendar := b.String()
+
+	for _, line := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Premodern Onsdagar\r\n",
+		"TZID:Europe/Stockholm\r\n",
+		"DTSTART;TZID=Europe/Stockholm:20251001T170000\r\n",
+		"DTEND;TZID=Europe/Stockholm:20251001T210000\r\n",
+		"LOCATION:Biljardpalatset\\, Fridhemsplan\r\n",
+		"DTSTART;VALUE=DATE:20251224\r\n",
+		"DTEND;VALUE=DATE:20251225\r\n",
+		"DESCRIPTION:Christmas\\; no event\r\n",
+		"STATUS:CANCELLED\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(calendar, line) {
+			t.Errorf("Expected the calendar to contain %q", line)
+		}
+	}
+	if strings.Count(calendar, "BEGIN:VEVENT") != 2 {
+		t.Errorf("Expected 2 events, got %d", strings.Count(calendar, "BEGIN:VEVENT"))
+	}
+}
+
+func TestFold(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("å", 60)
+	folded := fold(line)
+
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > lineLength {
+			t.Errorf("Expected lines of at most %d bytes, got %d", lineLength, len(part))
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != line {
+		t.Error("Expected unfolding to give back the line")
+	}
+	if !strings.HasPrefix(strings.Split(folded, "\r\n")[1], " å") {
+		t.Error("Expected characters not to be split between lines")
+	}
+}
@@ -34,6 +34,17 @@ func (db *CardDatabase) GetCardCount() int {
 	return len(db.cards)
 }
 
+// ImageURLs returns the image URL of every card
+func (db *CardDatabase) ImageURLs() []string {
+	urls := make([]string, 0, len(db.cards))
+	for _, card := range db.cards {
+		if card.ImageURL != "" {
+			urls = append(urls, card.ImageURL)
+		}
+	}
+	return urls
+}
+
 func normalizeString(s string) string {
 	// Remove leading digits
 	s = strings.TrimLeftFunc(s, unicode.IsDigit)
@@ -0,0 +1,168 @@
+package cardmatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const bulkDataURL = "https://api.scryfall.com/bulk-data"
+
+// The sets printed from Fourth Edition to Scourge, the card pool of Premodern
+var premodernSets = map[string]bool{
+	"4ed": true, "ice": true, "chr": true, "hml": true, "all": true, "mir": true, "vis": true, "5ed": true,
+	"wth": true, "tmp": true, "sth": true, "exo": true, "usg": true, "ulg": true, "6ed": true, "uds": true,
+	"mmq": true, "nem": true, "pcy": true, "inv": true, "pls": true, "7ed": true, "apc": true, "ody": true,
+	"tor": true, "jud": true, "ons": true, "lgn": true, "scg": true,
+}
+
+// scryfallCard is the part of a card in Scryfall's bulk data the card database is built from
+type scryfallCard struct {
+	Name        string            `json:"name"`
+	Set         string            `json:"set"`
+	TypeLine    string            `json:"type_line"`
+	BorderColor string            `json:"border_color"`
+	Finishes    []string          `json:"finishes"`
+	Legalities  map[string]string `json:"legalities"`
+	ImageURIs   map[string]string `json:"image_uris"`
+}
+
+// DownloadBulkData opens Scryfall's bulk data file with every printing of every card
+func DownloadBulkData(ctx context.Context, client *http.Client) (io.ReadCloser, error) {
+	var index struct {
+		Data []struct {
+			Type        string `json:"type"`
+			DownloadURI string `json:"download_uri"`
+		} `json:"data"`
+	}
+	body, err := scryfallGet(ctx, client, bulkDataURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to parse bulk data list: %w", err)
+	}
+
+	for _, file := range index.Data {
+		if file.Type == "default_cards" {
+			return scryfallGet(ctx, client, file.DownloadURI)
+		}
+	}
+	return nil, fmt.Errorf("no default_cards file in the bulk data list")
+}
+
+func scryfallGet(ctx context.Context, client *http.Client, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Scryfall asks API clients to identify themselves
+	req.Header.Set("User-Agent", "premodernonsdagar/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// BuildDatabase picks one printing of every card in a Premodern set from Scryfall's default_cards bulk data.
+// White bordered printings are only used for cards without a black bordered one.
+func BuildDatabase(bulkData io.Reader) ([]Card, error) {
+	decoder := json.NewDecoder(bulkData)
+	start, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bulk data: %w", err)
+	}
+	if start != json.Delim('[') {
+		return nil, fmt.Errorf("failed to parse bulk data: expected a list of cards")
+	}
+
+	cards := []Card{}
+	added := make(map[string]bool)
+	whiteBordered := make(map[string]Card)
+	for decoder.More() {
+		var printing scryfallCard
+		if err := decoder.Decode(&printing); err != nil {
+			return nil, fmt.Errorf("failed to parse bulk data: %w", err)
+		}
+
+		legality := printing.Legalities["premodern"]
+		if !slices.Contains(printing.Finishes, "nonfoil") || added[printing.Name] ||
+			legality == "not_legal" || !premodernSets[printing.Set] {
+			continue
+		}
+
+		cardType := "other"
+		if strings.Contains(printing.TypeLine, "Land") {
+			cardType = "land"
+		} else if strings.Contains(printing.TypeLine, "Creature") {
+			cardType = "creature"
+		}
+		card := Card{
+			Name:     printing.Name,
+			ImageURL: printing.ImageURIs["border_crop"],
+			Legality: legality,
+			CardType: cardType,
+		}
+
+		if printing.BorderColor == "white" {
+			if _, exists := whiteBordered[card.Name]; !exists {
+				whiteBordered[card.Name] = card
+			}
+			continue
+		}
+		cards = append(cards, card)
+		added[card.Name] = true
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("failed to parse bulk data: %w", err)
+	}
+
+	for name, card := range whiteBordered {
+		if !added[name] {
+			cards = append(cards, card)
+		}
+	}
+
+	slices.SortFunc(cards, func(a, b Card) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cards, nil
+}
+
+// SaveDatabase writes the cards to a card database file, replacing it only once written in full
+func SaveDatabase(path string, cards []Card) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), ".db-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create card database file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	encoder := json.NewEncoder(temp)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cards); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write card database: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write card database: %w", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace card database: %w", err)
+	}
+	return nil
+}
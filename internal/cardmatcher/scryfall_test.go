@@ -0,0 +1,61 @@
+package cardmatcher
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildDatabase(t *testing.T) {
+	bulkData := `[
+		{"name": "Swords to Plowshares", "set": "ice", "type_line": "Instant", "border_color": "white",
+		 "finishes": ["nonfoil"], "legalities": {"premodern": "legal"}, "image_uris": {"border_crop": "https://cards.scryfall.io/white.jpg"}},
+		{"name": "Swords to Plowshares", "set": "me4", "type_line": "Instant", "border_color": "black",
+		 "finishes": ["nonfoil"], "legalities": {"premodern": "legal"}, "image_uris": {"border_crop": "https://cards.scryfall.io/me4.jpg"}},
+		{"name": "Swords to Plowshares", "set": "ice", "type_line": "Instant", "border_color": "black",
+		 "finishes": ["nonfoil"], "legalities": {"premodern": "legal"}, "image_uris": {"border_crop": "https://cards.scryfall.io/ice.jpg"}},
+		{"name": "Mother of Runes", "set": "ulg", "type_line": "Creature — Human Cleric", "border_color": "black",
+		 "finishes": ["foil"], "legalities": {"premodern": "legal"}},
+		{"name": "Mother of Runes", "set": "ulg", "type_line": "Creature — Human Cleric", "border_color": "black",
+		 "finishes": ["nonfoil", "foil"], "legalities": {"premodern": "legal"}},
+		{"name": "Plains", "set": "4ed", "type_line": "Basic Land — Plains", "border_color": "white",
+		 "finishes": ["nonfoil"], "legalities": {"premodern": "legal"}},
+		{"name": "Force of Will", "set": "all", "type_line": "Instant", "border_color": "black",
+		 "finishes": ["nonfoil"], "legalities": {"premodern": "not_legal"}},
+		{"name": "Entomb", "set": "ody", "type_line": "Instant", "border_color": "black",
+		 "finishes": ["nonfoil"], "legalities": {"premodern": "banned"}}
+	]`
+
+	cards, err := BuildDatabase(strings.NewReader(bulkData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Card{
+		{Name: "Entomb", Legality: "banned", CardType: "other"},
+		{Name: "Mother of Runes", Legality: "legal", CardType: "creature"},
+		{Name: "Plains", Legality: "legal", CardType: "land"},
+		{Name: "Swords to Plowshares", ImageURL: "https://cards.scryfall.io/ice.jpg", Legality: "legal", CardType: "other"},
+	}
+	if len(cards) != len(expected) {
+		t.Fatalf("Expected %d cards, got %+v", len(expected), cards)
+	}
+	for i := range expected {
+		if cards[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], cards[i])
+		}
+	}
+
+	if _, err := BuildDatabase(strings.NewReader(`{"object": "error"}`)); err == nil {
+		t.Error("Expected an error for a response that is not a list of cards")
+	}
+
+	path := filepath.Join(t.TempDir(), "db.json")
+	if err := SaveDatabase(path, cards); err != nil {
+		t.Fatal(err)
+	}
+	db := NewCardDatabase()
+	if err := db.LoadDatabase(path); err != nil || db.GetCardCount() != len(cards) {
+		t.Errorf("Expected the saved database to load %d cards, got %d (%v)", len(cards), db.GetCardCount(), err)
+	}
+}
@@ -0,0 +1,142 @@
+// Package compress gzips the responses for clients that accept it, for the phones on slow connections at the venue.
+package compress
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Responses smaller than this are sent as they are, compressing them saves too little
+const minSize = 1024
+
+var writers = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// compressible lists the content types worth compressing besides text/*, images other than icons are compressed already
+var compressible = map[string]bool{
+	"application/json":         true,
+	"application/javascript":   true,
+	"image/svg+xml":            true,
+	"image/x-icon":             true,
+	"image/vnd.microsoft.icon": true,
+}
+
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressible[mediaType]
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip, a gzip entry takes precedence over *
+func acceptsGzip(header string) bool {
+	// The quality of gzip and *, -1 when not listed
+	gzipQ, starQ := -1.0, -1.0
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if _, value, found := strings.Cut(params, "q="); found {
+			q, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		}
+		switch strings.TrimSpace(coding) {
+		case "gzip":
+			gzipQ = q
+		case "*":
+			starQ = q
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return starQ > 0
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	h := w.Header()
+	if !isCompressible(h.Get("Content-Type")) {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	h.Add("Vary", "Accept-Encoding")
+
+	// Ranges and responses without a body are left alone, as are small responses of a known size
+	length, err := strconv.Atoi(h.Get("Content-Length"))
+	small := err == nil && length < minSize
+	if status < 200 || status == http.StatusNoContent || status == http.StatusPartialContent || status == http.StatusNotModified ||
+		small || h.Get("Content-Encoding") != "" {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", "gzip")
+	// The compressed body differs from the uncompressed one byte for byte
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+	w.gz = writers.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) close() {
+	if w.gz == nil {
+		return
+	}
+	w.gz.Close()
+	w.gz.Reset(nil)
+	writers.Put(w.gz)
+}
+
+// Middleware compresses the text responses with gzip when the client accepts it
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &responseWriter{ResponseWriter: w}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
@@ -0,0 +1,74 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                      false,
+		"gzip, deflate, br":     true,
+		"br;q=1.0, gzip;q=0.8":  true,
+		"gzip;q=0":              false,
+		"identity, *;q=0.5":     true,
+		"deflate, br, identity": false,
+		"gzip;q=0, *;q=0.1":     false,
+	}
+	for header, expected := range tests {
+		if got := acceptsGzip(header); got != expected {
+			t.Errorf("acceptsGzip(%q) = %v, expected %v", header, got, expected)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	page := strings.Repeat("<p>Onsdagstävling</p>\n", 200)
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("ETag", `"abc"`)
+			io.WriteString(w, page)
+		case "/small":
+			w.Header().Set("Content-Type", "text/css")
+			w.Header().Set("Content-Length", "4")
+			io.WriteString(w, "p{ }")
+		case "/image":
+			w.Header().Set("Content-Type", "image/jpeg")
+			io.WriteString(w, page)
+		}
+	}))
+	get := func(path, encoding string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept-Encoding", encoding)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/page", "gzip")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("ETag") != `W/"abc"` {
+		t.Fatalf("Expected a gzipped page with a weak ETag, got %v", w.Header())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(reader); string(body) != page {
+		t.Error("Expected the page to decompress to the original")
+	}
+
+	if w := get("/page", ""); w.Header().Get("Content-Encoding") != "" || w.Body.String() != page {
+		t.Error("Expected the page as it is without gzip in Accept-Encoding")
+	}
+	if w := get("/small", "gzip"); w.Header().Get("Content-Encoding") != "" || w.Body.String() != "p{ }" {
+		t.Error("Expected a small response to be sent as it is")
+	}
+	if w := get("/image", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Error("Expected an image to be sent as it is")
+	}
+}
@@ -1,17 +1,170 @@
 package config
 
-import "os"
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RatingModeMatch = "match"
+	RatingModeGame  = "game"
+)
 
 type Config struct {
 	DevelopmentEnvironment bool
+	RatingMode             string
+	AdminEnabled           bool
+	AdminUsers             string // Comma separated "name:hash" pairs, see --hash-password
+	TrustedProxies         string // Comma separated addresses or CIDR ranges of the reverse proxies in front of the server
+	MetricsToken           string // Bearer token needed for /metrics, which is only served without one when developing
+	DatabasePath           string // SQLite database with the aggregated data, the JSON files are used when empty
+	ImageCacheSize         int64  // Bytes of card images kept in ImageCacheDir
+	ImageCacheDir          string
+
+	// DataDir holds input/ and files/, the working directory when empty
+	DataDir string
+
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration // How long open requests may take to finish when stopping
+
+	// S3-compatible bucket the input files are synced with, not used when InputBucket is empty
+	InputBucket       string
+	InputPrefix       string
+	InputEndpoint     string
+	InputRegion       string
+	InputAccessKey    string
+	InputSecretKey    string
+	InputSyncInterval time.Duration
+}
+
+// settings are the values from the config file, overridden by the environment variables of the same name
+type settings map[string]string
+
+func (s settings) lookup(key string) (string, bool) {
+	if value, exists := os.LookupEnv(key); exists {
+		return value, true
+	}
+	value, exists := s[key]
+	return value, exists
 }
 
-func GetConfig() Config {
+func (s settings) get(key string) string {
+	value, _ := s.lookup(key)
+	return value
+}
+
+func (s settings) duration(key string, fallback time.Duration) time.Duration {
+	value, exists := s.lookup(key)
+	if !exists {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Warn("Ignoring invalid duration", "setting", key, "value", value, "err", err)
+		return fallback
+	}
+	return parsed
+}
+
+// readConfigFile reads KEY=value lines, skipping empty lines and lines starting with #
+func readConfigFile(path string) (settings, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	values := settings{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid line %d in config file %s: expected KEY=value", line, path)
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return values, nil
+}
+
+// GetConfig reads the config from the environment variables, and from the file named by CONFIG_FILE when set
+func GetConfig() (Config, error) {
+	s := settings{}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		var err error
+		if s, err = readConfigFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
 	appConfig := Config{
 		DevelopmentEnvironment: false,
+		RatingMode:             RatingModeMatch,
 	}
-	if devEnv, exists := os.LookupEnv("DEVENV"); exists && devEnv == "1" {
+	if devEnv, exists := s.lookup("DEVENV"); exists && devEnv == "1" {
 		appConfig.DevelopmentEnvironment = true
 	}
-	return appConfig
+	if ratingMode, exists := s.lookup("RATING_MODE"); exists && ratingMode == RatingModeGame {
+		appConfig.RatingMode = RatingModeGame
+	}
+	if adminEnabled, exists := s.lookup("ADMIN_ENABLED"); exists && adminEnabled == "1" {
+		appConfig.AdminEnabled = true
+	}
+	appConfig.AdminUsers = s.get("ADMIN_USERS")
+	appConfig.TrustedProxies = s.get("TRUSTED_PROXIES")
+	appConfig.MetricsToken = s.get("METRICS_TOKEN")
+	appConfig.DatabasePath = s.get("DATABASE_PATH")
+	appConfig.ImageCacheSize = 1024 << 20
+	if size, exists := s.lookup("IMAGE_CACHE_MB"); exists {
+		if parsed, err := strconv.ParseInt(size, 10, 64); err == nil && parsed > 0 {
+			appConfig.ImageCacheSize = parsed << 20
+		} else {
+			slog.Warn("Ignoring invalid IMAGE_CACHE_MB", "value", size)
+		}
+	}
+	appConfig.ImageCacheDir = filepath.Join("static", "images", "scryfall")
+	if dir := s.get("IMAGE_CACHE_DIR"); dir != "" {
+		appConfig.ImageCacheDir = dir
+	}
+	appConfig.DataDir = s.get("DATA_DIR")
+
+	appConfig.Addr = ":8080"
+	if addr := s.get("ADDR"); addr != "" {
+		appConfig.Addr = addr
+	}
+	appConfig.ReadTimeout = s.duration("READ_TIMEOUT", 30*time.Second)
+	// Long enough for a card image to be fetched from Scryfall
+	appConfig.WriteTimeout = s.duration("WRITE_TIMEOUT", 60*time.Second)
+	appConfig.IdleTimeout = s.duration("IDLE_TIMEOUT", 2*time.Minute)
+	// Docker kills the process 10 seconds after asking it to stop
+	appConfig.ShutdownTimeout = s.duration("SHUTDOWN_TIMEOUT", 8*time.Second)
+
+	appConfig.InputBucket = s.get("INPUT_S3_BUCKET")
+	appConfig.InputPrefix = s.get("INPUT_S3_PREFIX")
+	appConfig.InputEndpoint = s.get("INPUT_S3_ENDPOINT")
+	appConfig.InputRegion = s.get("INPUT_S3_REGION")
+	appConfig.InputAccessKey = s.get("AWS_ACCESS_KEY_ID")
+	appConfig.InputSecretKey = s.get("AWS_SECRET_ACCESS_KEY")
+	appConfig.InputSyncInterval = s.duration("INPUT_SYNC_INTERVAL", 5*time.Minute)
+	return appConfig, nil
 }
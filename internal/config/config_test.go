@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.env")
+	content := `# Production settings
+ADDR=127.0.0.1:9000
+ADMIN_ENABLED=1
+ADMIN_USERS="alice:pbkdf2-sha256$600000$abc"
+TRUSTED_PROXIES=10.0.0.0/8
+
+WRITE_TIMEOUT = 90s
+RATING_MODE=match
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("RATING_MODE", RatingModeGame)
+
+	cfg, err := GetConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != "127.0.0.1:9000" || !cfg.AdminEnabled || cfg.WriteTimeout != 90*time.Second {
+		t.Errorf("Expected the settings from the file, got %+v", cfg)
+	}
+	if cfg.AdminUsers != "alice:pbkdf2-sha256$600000$abc" || cfg.TrustedProxies != "10.0.0.0/8" {
+		t.Errorf("Expected the quotes to be removed, got %q and %q", cfg.AdminUsers, cfg.TrustedProxies)
+	}
+	if cfg.RatingMode != RatingModeGame {
+		t.Errorf("Expected the environment to override the file, got %q", cfg.RatingMode)
+	}
+	if cfg.ReadTimeout != 30*time.Second || cfg.ShutdownTimeout != 8*time.Second {
+		t.Errorf("Expected the default timeouts, got %s and %s", cfg.ReadTimeout, cfg.ShutdownTimeout)
+	}
+
+	if err := os.WriteFile(path, []byte("ADDR\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetConfig(); err == nil {
+		t.Error("Expected an error for a line without a value")
+	}
+}
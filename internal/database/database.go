@@ -0,0 +1,319 @@
+// Package database stores the aggregated data of every series in SQLite.
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so the binary builds without cgo
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS documents (
+	series TEXT NOT NULL,
+	path TEXT NOT NULL,
+	data BLOB NOT NULL,
+	PRIMARY KEY (series, path)
+);
+CREATE TABLE IF NOT EXISTS events (
+	series TEXT NOT NULL,
+	date TEXT NOT NULL,
+	name TEXT NOT NULL,
+	season TEXT NOT NULL,
+	rounds INTEGER NOT NULL,
+	attendance INTEGER NOT NULL,
+	PRIMARY KEY (series, date)
+);
+CREATE TABLE IF NOT EXISTS event_results (
+	series TEXT NOT NULL,
+	date TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	player TEXT NOT NULL,
+	record TEXT NOT NULL,
+	deck TEXT NOT NULL,
+	decklist TEXT NOT NULL,
+	PRIMARY KEY (series, date, player)
+);
+CREATE TABLE IF NOT EXISTS matches (
+	series TEXT NOT NULL,
+	date TEXT NOT NULL,
+	player_1 TEXT NOT NULL,
+	player_2 TEXT NOT NULL,
+	result TEXT NOT NULL,
+	player_1_extra_match INTEGER NOT NULL,
+	player_2_extra_match INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS matches_series_date ON matches (series, date);
+CREATE TABLE IF NOT EXISTS players (
+	series TEXT NOT NULL,
+	slug TEXT NOT NULL,
+	name TEXT NOT NULL,
+	attended_events INTEGER NOT NULL,
+	matches_played INTEGER NOT NULL,
+	match_win_rate REAL NOT NULL,
+	game_win_rate REAL NOT NULL,
+	elo_rating INTEGER NOT NULL,
+	glicko_rating REAL NOT NULL,
+	glicko_deviation REAL NOT NULL,
+	glicko_volatility REAL NOT NULL,
+	PRIMARY KEY (series, slug)
+);
+CREATE TABLE IF NOT EXISTS ratings (
+	series TEXT NOT NULL,
+	slug TEXT NOT NULL,
+	system TEXT NOT NULL,
+	date TEXT NOT NULL,
+	rating REAL NOT NULL
+);
+CREATE INDEX IF NOT EXISTS ratings_series_slug ON ratings (series, slug);
+CREATE TABLE IF NOT EXISTS decklists (
+	series TEXT NOT NULL,
+	id TEXT NOT NULL,
+	event TEXT NOT NULL,
+	player TEXT NOT NULL,
+	deck TEXT NOT NULL,
+	PRIMARY KEY (series, id)
+);
+CREATE TABLE IF NOT EXISTS decklist_cards (
+	series TEXT NOT NULL,
+	decklist TEXT NOT NULL,
+	section TEXT NOT NULL,
+	count INTEGER NOT NULL,
+	card TEXT NOT NULL,
+	card_type TEXT NOT NULL,
+	legality TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS decklist_cards_series_decklist ON decklist_cards (series, decklist);
+`
+
+// The tables holding the data of the series, in the order they are cleared
+var seriesTables = []string{"documents", "events", "event_results", "matches", "players", "ratings", "decklists", "decklist_cards"}
+
+type DB struct {
+	db *sql.DB
+}
+
+// Document is an aggregated JSON file, with its path relative to the output directory of the series
+type Document struct {
+	Path string
+	Data []byte
+}
+
+type Event struct {
+	Date       string
+	Name       string
+	Season     string
+	Rounds     int
+	Attendance int
+	Results    []Result
+	Matches    []Match
+}
+
+type Result struct {
+	Player   string
+	Record   string
+	Deck     string
+	Decklist string
+}
+
+type Match struct {
+	Player1           string
+	Player2           string
+	Result            string
+	Player1ExtraMatch bool
+	Player2ExtraMatch bool
+}
+
+type Player struct {
+	Slug             string
+	Name             string
+	AttendedEvents   int
+	MatchesPlayed    int
+	MatchWinRate     float64
+	GameWinRate      float64
+	EloRating        int
+	GlickoRating     float64
+	GlickoDeviation  float64
+	GlickoVolatility float64
+	Ratings          []Rating
+}
+
+// Rating is a player's rating in one of the rating systems after an event
+type Rating struct {
+	System string
+	Date   string
+	Rating float64
+}
+
+type Decklist struct {
+	ID     string
+	Event  string
+	Player string
+	Deck   string
+	Cards  []Card
+}
+
+type Card struct {
+	Section  string
+	Count    int
+	Name     string
+	CardType string
+	Legality string
+}
+
+// Series is everything aggregated for a series
+type Series struct {
+	Documents []Document
+	Events    []Event
+	Players   []Player
+	Decklists []Decklist
+}
+
+// Open opens the database at path, creating it and its tables when missing
+func Open(path string) (*DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	// Writes are serialized by SQLite anyway, and a single connection avoids busy errors
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database tables: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// ReplaceAll stores the data of every series, by series ID, in one transaction, removing whatever was stored before
+func (d *DB) ReplaceAll(all map[string]Series) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range seriesTables {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	for id, data := range all {
+		if err := insertSeries(tx, id, data); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit series: %w", err)
+	}
+	return nil
+}
+
+func insertSeries(tx *sql.Tx, id string, data Series) error {
+	for _, doc := range data.Documents {
+		if _, err := tx.Exec("INSERT INTO documents (series, path, data) VALUES (?, ?, ?)", id, doc.Path, doc.Data); err != nil {
+			return fmt.Errorf("failed to insert document %s: %w", doc.Path, err)
+		}
+	}
+
+	for _, event := range data.Events {
+		if err := insertEvent(tx, id, event); err != nil {
+			return err
+		}
+	}
+
+	for _, player := range data.Players {
+		if err := insertPlayer(tx, id, player); err != nil {
+			return err
+		}
+	}
+
+	for _, decklist := range data.Decklists {
+		if err := insertDecklist(tx, id, decklist); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertEvent(tx *sql.Tx, series string, event Event) error {
+	_, err := tx.Exec("INSERT INTO events (series, date, name, season, rounds, attendance) VALUES (?, ?, ?, ?, ?, ?)",
+		series, event.Date, event.Name, event.Season, event.Rounds, event.Attendance)
+	if err != nil {
+		return fmt.Errorf("failed to insert event %s: %w", event.Date, err)
+	}
+
+	for i, result := range event.Results {
+		_, err := tx.Exec("INSERT INTO event_results (series, date, position, player, record, deck, decklist) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			series, event.Date, i+1, result.Player, result.Record, result.Deck, result.Decklist)
+		if err != nil {
+			return fmt.Errorf("failed to insert result of %s at %s: %w", result.Player, event.Date, err)
+		}
+	}
+
+	for _, match := range event.Matches {
+		_, err := tx.Exec("INSERT INTO matches (series, date, player_1, player_2, result, player_1_extra_match, player_2_extra_match) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			series, event.Date, match.Player1, match.Player2, match.Result, match.Player1ExtraMatch, match.Player2ExtraMatch)
+		if err != nil {
+			return fmt.Errorf("failed to insert match %s vs %s at %s: %w", match.Player1, match.Player2, event.Date, err)
+		}
+	}
+	return nil
+}
+
+func insertPlayer(tx *sql.Tx, series string, player Player) error {
+	_, err := tx.Exec(`INSERT INTO players (series, slug, name, attended_events, matches_played, match_win_rate, game_win_rate, elo_rating, glicko_rating, glicko_deviation, glicko_volatility)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		series, player.Slug, player.Name, player.AttendedEvents, player.MatchesPlayed, player.MatchWinRate, player.GameWinRate,
+		player.EloRating, player.GlickoRating, player.GlickoDeviation, player.GlickoVolatility)
+	if err != nil {
+		return fmt.Errorf("failed to insert player %s: %w", player.Name, err)
+	}
+
+	for _, rating := range player.Ratings {
+		_, err := tx.Exec("INSERT INTO ratings (series, slug, system, date, rating) VALUES (?, ?, ?, ?, ?)",
+			series, player.Slug, rating.System, rating.Date, rating.Rating)
+		if err != nil {
+			return fmt.Errorf("failed to insert %s rating of %s: %w", rating.System, player.Name, err)
+		}
+	}
+	return nil
+}
+
+func insertDecklist(tx *sql.Tx, series string, decklist Decklist) error {
+	_, err := tx.Exec("INSERT INTO decklists (series, id, event, player, deck) VALUES (?, ?, ?, ?, ?)",
+		series, decklist.ID, decklist.Event, decklist.Player, decklist.Deck)
+	if err != nil {
+		return fmt.Errorf("failed to insert decklist %s: %w", decklist.ID, err)
+	}
+
+	for _, card := range decklist.Cards {
+		_, err := tx.Exec("INSERT INTO decklist_cards (series, decklist, section, count, card, card_type, legality) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			series, decklist.ID, card.Section, card.Count, card.Name, card.CardType, card.Legality)
+		if err != nil {
+			return fmt.Errorf("failed to insert %s of decklist %s: %w", card.Name, decklist.ID, err)
+		}
+	}
+	return nil
+}
+
+// Document returns an aggregated JSON file of a series, or an error matching fs.ErrNotExist like a missing file
+func (d *DB) Document(series, path string) ([]byte, error) {
+	var data []byte
+	err := d.db.QueryRow("SELECT data FROM documents WHERE series = ? AND path = ?", series, path).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("document %s of series %s: %w", path, series, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document %s of series %s: %w", path, series, err)
+	}
+	return data, nil
+}
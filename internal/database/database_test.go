@@ -0,0 +1,77 @@
+package database
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+func count(t *testing.T, d *DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := d.db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("Query %q failed: %v", query, err)
+	}
+	return n
+}
+
+func TestReplaceAll(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	data := Series{
+		Documents: []Document{{Path: "events/2025-08-20.json", Data: []byte(`{"name": "Onsdagstävling"}`)}},
+		Events: []Event{{
+			Date:    "2025-08-20",
+			Name:    "Onsdagstävling",
+			Results: []Result{{Player: "Anna", Record: "1-0"}, {Player: "Erik", Record: "0-1"}},
+			Matches: []Match{{Player1: "Anna", Player2: "Erik", Result: "2-0"}},
+		}},
+		Players: []Player{{Slug: "anna", Name: "Anna", Ratings: []Rating{{System: "elo", Date: "2025-08-20", Rating: 1516}}}},
+		Decklists: []Decklist{{ID: "2025-08-20-anna", Player: "Anna", Cards: []Card{
+			{Section: "main", Count: 4, Name: "Goblin Lackey"},
+			{Section: "sideboard", Count: 2, Name: "Pyroblast"},
+		}}},
+	}
+	oldSchool := Series{Events: []Event{{Date: "2025-08-23", Name: "Old School"}}}
+	if err := d.ReplaceAll(map[string]Series{"onsdagar": data, "oldschool": oldSchool}); err != nil {
+		t.Fatalf("ReplaceAll failed: %v", err)
+	}
+
+	content, err := d.Document("onsdagar", "events/2025-08-20.json")
+	if err != nil || string(content) != `{"name": "Onsdagstävling"}` {
+		t.Errorf("Expected the stored document, got %q and %v", content, err)
+	}
+	if _, err := d.Document("oldschool", "events/2025-08-20.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a missing document to be reported as not existing, got %v", err)
+	}
+	if n := count(t, d, "SELECT COUNT(*) FROM event_results WHERE series = 'onsdagar' AND position = 2 AND player = 'Erik'"); n != 1 {
+		t.Errorf("Expected the results to be stored in order, got %d", n)
+	}
+	if n := count(t, d, "SELECT SUM(count) FROM decklist_cards WHERE decklist = '2025-08-20-anna'"); n != 6 {
+		t.Errorf("Expected 6 decklist cards, got %d", n)
+	}
+
+	// Replacing the series removes what was stored before, including series that are gone
+	data.Players = nil
+	data.Events[0].Matches = nil
+	if err := d.ReplaceAll(map[string]Series{"onsdagar": data}); err != nil {
+		t.Fatalf("ReplaceAll failed: %v", err)
+	}
+	if n := count(t, d, "SELECT COUNT(*) FROM players"); n != 0 {
+		t.Errorf("Expected the removed player to be gone, got %d players", n)
+	}
+	if n := count(t, d, "SELECT COUNT(*) FROM ratings"); n != 0 {
+		t.Errorf("Expected the ratings of the removed player to be gone, got %d", n)
+	}
+	if n := count(t, d, "SELECT COUNT(*) FROM matches"); n != 0 {
+		t.Errorf("Expected the removed match to be gone, got %d", n)
+	}
+	if n := count(t, d, "SELECT COUNT(*) FROM events"); n != 1 {
+		t.Errorf("Expected only the event of the stored series, got %d", n)
+	}
+}
@@ -0,0 +1,90 @@
+// Package decklists saves the decklists players bring to events, for the admin section and the command line.
+package decklists
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/audit"
+	"premodernonsdagar/internal/series"
+	"premodernonsdagar/internal/utils"
+)
+
+var ErrEmpty = errors.New("the decklist is empty")
+
+// Dir holds the decklist files of the series
+func Dir(s series.Series) string {
+	return filepath.Join(s.InputDir(), "decklists")
+}
+
+// BaseName is the name of a player's decklist file in the event, without the extension
+func BaseName(date, player string) string {
+	return date + "-" + utils.Slugify(player)
+}
+
+// EventPlayers returns everyone in the event, including players who are only in the matches
+func EventPlayers(event *aggregation.InputEvent) []string {
+	seen := make(map[string]bool)
+	players := []string{}
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			players = append(players, name)
+		}
+	}
+
+	for name := range event.PlayerInfo {
+		add(name)
+	}
+	for _, match := range event.Matches {
+		add(match.Player1)
+		add(match.Player2)
+	}
+
+	sort.Strings(players)
+	return players
+}
+
+// FindPlayer returns the name used in the event for the player with the slug
+func FindPlayer(event *aggregation.InputEvent, slug string) (string, bool) {
+	for _, name := range EventPlayers(event) {
+		if utils.Slugify(name) == slug {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Save writes the decklist of a player in the event and links it from the event file, setting the deck name too
+// unless it is empty
+func Save(s series.Series, event *aggregation.InputEvent, player, content, deck, user string) error {
+	content = strings.TrimSpace(strings.ReplaceAll(content, "\r\n", "\n"))
+	if content == "" {
+		return ErrEmpty
+	}
+
+	baseName := BaseName(event.Date, player)
+	if err := os.MkdirAll(Dir(s), 0755); err != nil {
+		return fmt.Errorf("failed to create decklists directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(Dir(s), baseName+".txt"), []byte(content+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write decklist: %w", err)
+	}
+
+	if event.PlayerInfo == nil {
+		event.PlayerInfo = make(map[string]aggregation.PlayerEventInfo)
+	}
+	info := event.PlayerInfo[player]
+	info.Decklist = baseName
+	if deck = strings.TrimSpace(deck); deck != "" {
+		info.Deck = deck
+	}
+	event.PlayerInfo[player] = info
+
+	return audit.SaveEvent(s.InputDir(), user, audit.ActionUpdate, event.Date, *event)
+}
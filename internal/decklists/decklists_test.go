@@ -0,0 +1,49 @@
+package decklists
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/audit"
+	"premodernonsdagar/internal/series"
+)
+
+func TestSave(t *testing.T) {
+	t.Chdir(t.TempDir())
+	s := series.Default()
+
+	event := &aggregation.InputEvent{
+		Name:    "Onsdag",
+		Date:    "2025-08-20",
+		Matches: []aggregation.Match{{Player1: "Anna Lifebinder", Player2: "Bob", Result: "2-0"}},
+	}
+	player, found := FindPlayer(event, "anna-lifebinder")
+	if !found || player != "Anna Lifebinder" {
+		t.Fatalf("Expected to find the player from the matches by slug, got %q", player)
+	}
+
+	if err := Save(s, event, player, "  \r\n", "Elves", "alice"); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Expected an empty decklist to be refused, got %v", err)
+	}
+	if err := Save(s, event, player, "4 Llanowar Elves\r\n4 Quirion Ranger\r\n", " Elves ", "alice"); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	content, err := os.ReadFile("input/decklists/2025-08-20-anna-lifebinder.txt")
+	if err != nil {
+		t.Fatalf("Expected the decklist file to be written: %v", err)
+	}
+	if string(content) != "4 Llanowar Elves\n4 Quirion Ranger\n" {
+		t.Errorf("Unexpected decklist %q", content)
+	}
+
+	saved, err := audit.CurrentEvent("input", "2025-08-20")
+	if err != nil || saved == nil {
+		t.Fatalf("Expected the event to be saved, got %v", err)
+	}
+	if info := saved.PlayerInfo[player]; info.Decklist != "2025-08-20-anna-lifebinder" || info.Deck != "Elves" {
+		t.Errorf("Expected the event to link the decklist and deck, got %+v", info)
+	}
+}
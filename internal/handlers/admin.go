@@ -5,39 +5,53 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/audit"
+	"premodernonsdagar/internal/auth"
+	"premodernonsdagar/internal/series"
 	"premodernonsdagar/internal/templates"
 )
 
-// getAvailablePlayerNames reads and extracts player names from the players.json file
+// getAvailablePlayerNames reads and extracts player names from the players.json files of every series
 func getAvailablePlayerNames() ([]string, error) {
-	fileContent, err := os.ReadFile("files/lists/players.json")
+	allSeries, err := loadSeries()
 	if err != nil {
-		// If players file doesn't exist, continue with empty list
-		log.Printf("Warning: Could not read players file: %v", err)
-		return []string{}, nil
+		return nil, err
 	}
 
-	var playersData []map[string]interface{}
-	err = json.Unmarshal(fileContent, &playersData)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling players data: %w", err)
-	}
-
-	// Extract player names
+	seen := make(map[string]bool)
 	var playerNames []string
-	for _, player := range playersData {
-		if name, ok := player["name"].(string); ok {
-			playerNames = append(playerNames, name)
+	for _, s := range allSeries {
+		fileContent, err := readSeriesFile(s, "lists", "players.json")
+		if err != nil {
+			// If players file doesn't exist, continue with the other series
+			slog.Warn("Could not read players file", "series", s.ID, "err", err)
+			continue
+		}
+
+		var playersData []map[string]interface{}
+		err = json.Unmarshal(fileContent, &playersData)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling players data: %w", err)
+		}
+
+		// Extract player names
+		for _, player := range playersData {
+			if name, ok := player["name"].(string); ok && !seen[name] {
+				seen[name] = true
+				playerNames = append(playerNames, name)
+			}
 		}
 	}
+	sort.Strings(playerNames)
 
 	return playerNames, nil
 }
@@ -88,15 +102,107 @@ func parseMatchResults(r *http.Request) ([]aggregation.Match, error) {
 	return matches, nil
 }
 
+// parseRounds reads the number of rounds from the form, defaulting to the usual number for the series
+func parseRounds(r *http.Request, s series.Series) int {
+	rounds, err := strconv.Atoi(r.FormValue("rounds"))
+	if err != nil {
+		return s.Rounds
+	}
+	return rounds
+}
+
+// eventFromForm reads the event from the event form. The message says what is missing if the form could not be read,
+// the event then holds what was read so the form can be shown again.
+func eventFromForm(r *http.Request, s series.Series) (aggregation.InputEvent, string) {
+	event := aggregation.InputEvent{
+		Name:       r.FormValue("event_name"),
+		Date:       r.FormValue("event_date"),
+		Rounds:     parseRounds(r, s),
+		PlayerInfo: make(map[string]aggregation.PlayerEventInfo),
+	}
+	if event.Name == "" || event.Date == "" {
+		return event, "Event name and date are required"
+	}
+
+	// Parse match results from form
+	matches, err := parseMatchResults(r)
+	if err != nil {
+		return event, err.Error()
+	}
+	event.Matches = matches
+
+	// Initialize player info for all players in the matches
+	for _, match := range matches {
+		for _, player := range []string{match.Player1, match.Player2} {
+			event.PlayerInfo[player] = aggregation.PlayerEventInfo{
+				Deck: r.FormValue(fmt.Sprintf("player_deck_%s", player)),
+			}
+		}
+	}
+	return event, ""
+}
+
+// renderEventForm shows the event form filled in with the event, if any, and the issues found in it.
+// editDate is the date of the event being edited, or empty for a new event.
+func renderEventForm(w http.ResponseWriter, r *http.Request, s series.Series, status int, message, editDate string, event *aggregation.InputEvent, report aggregation.ValidationReport) {
+	playerNames, err := getAvailablePlayerNames()
+	if err != nil {
+		http.Error(w, "Error loading players data", http.StatusInternalServerError)
+		return
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage":    "events",
+		"Scheme":        templates.ColorScheme(),
+		"PlayerNames":   playerNames,
+		"ExistingEvent": event,
+		"IsEdit":        editDate != "",
+		"EditDate":      editDate,
+		"Error":         message,
+		"Report":        report,
+		"CSRFToken":     auth.CSRFToken(r),
+		"EventsURL":     adminURL(s, "/events"),
+		"DefaultRounds": s.Rounds,
+	}
+	templates.RenderTemplateStatus(w, r, status, "admin_event.tmpl", templateData)
+}
+
+// eventDateFree reports whether the event can be saved on its date without replacing another event,
+// responding with the form and a conflict otherwise
+func eventDateFree(w http.ResponseWriter, r *http.Request, s series.Series, event aggregation.InputEvent, editDate string) bool {
+	existing, err := audit.CurrentEvent(s.InputDir(), event.Date)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading event", "event_date", event.Date, "err", err)
+		http.Error(w, "Error reading event", http.StatusInternalServerError)
+		return false
+	}
+	if existing != nil {
+		renderEventForm(w, r, s, http.StatusConflict, "There is already an event on "+event.Date+", edit it instead or pick another date", editDate, &event, aggregation.ValidationReport{})
+		return false
+	}
+	return true
+}
+
+type adminSeriesLink struct {
+	Name   string
+	URL    string
+	Active bool
+}
+
 func AdminEventsListHandler(w http.ResponseWriter, r *http.Request) {
-	// Read events from input/events directory
-	inputEventsDir := "input/events"
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	// Read events from the events directory of the series
+	inputEventsDir := filepath.Join(s.InputDir(), "events")
 	var eventItems []aggregation.EventListItem
 
 	// Check if directory exists
 	if _, err := os.Stat(inputEventsDir); os.IsNotExist(err) {
 		// Directory doesn't exist, show empty list
-		log.Printf("Warning: input/events directory does not exist")
+		slog.WarnContext(r.Context(), "Input events directory does not exist", "dir", inputEventsDir)
 	} else {
 		// Read all JSON files in the directory
 		err := filepath.WalkDir(inputEventsDir, func(path string, d fs.DirEntry, err error) error {
@@ -108,13 +214,13 @@ func AdminEventsListHandler(w http.ResponseWriter, r *http.Request) {
 				// Read and parse the event file
 				fileContent, readErr := os.ReadFile(path)
 				if readErr != nil {
-					log.Printf("Error reading event file %s: %v", path, readErr)
+					slog.ErrorContext(r.Context(), "Error reading event file", "path", path, "err", readErr)
 					return nil
 				}
 
 				var event aggregation.InputEvent
 				if parseErr := json.Unmarshal(fileContent, &event); parseErr != nil {
-					log.Printf("Error parsing event file %s: %v", path, parseErr)
+					slog.ErrorContext(r.Context(), "Error parsing event file", "path", path, "err", parseErr)
 					return nil
 				}
 
@@ -129,7 +235,7 @@ func AdminEventsListHandler(w http.ResponseWriter, r *http.Request) {
 					Name:   event.Name,
 					Date:   event.Date,
 					Season: season,
-					URL:    fmt.Sprintf("/admin/events/edit/%s", event.Date),
+					URL:    adminURL(s, "/events/"+event.Date+"/edit"),
 				}
 
 				eventItems = append(eventItems, eventItem)
@@ -138,7 +244,7 @@ func AdminEventsListHandler(w http.ResponseWriter, r *http.Request) {
 		})
 
 		if err != nil {
-			log.Printf("Error walking input/events directory: %v", err)
+			slog.ErrorContext(r.Context(), "Error walking directory", "dir", inputEventsDir, "err", err)
 			http.Error(w, "Error reading events directory", http.StatusInternalServerError)
 			return
 		}
@@ -157,114 +263,96 @@ func AdminEventsListHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	// Only offer to switch series when there is more than one
+	var seriesLinks []adminSeriesLink
+	if allSeries, err := loadSeries(); err == nil && len(allSeries) > 1 {
+		for _, other := range allSeries {
+			seriesLinks = append(seriesLinks, adminSeriesLink{
+				Name:   other.Name,
+				URL:    adminURL(other, "/events"),
+				Active: other.ID == s.ID,
+			})
+		}
+	}
+
 	templateData := map[string]interface{}{
 		"ActivePage": "admin",
 		"Scheme":     templates.ColorScheme(),
 		"Stats":      stats,
 		"Events":     eventItems,
 		"IsAdmin":    true,
+		"CSRFToken":  auth.CSRFToken(r),
+		"Username":   auth.Username(r),
+		"EventsURL":  adminURL(s, "/events"),
+		"SignupsURL": adminURL(s, "/signups"),
+		"AllSeries":  seriesLinks,
 	}
 
-	templates.RenderTemplate(w, "admin_events.tmpl", templateData)
+	templates.RenderTemplate(w, r, "admin_events.tmpl", templateData)
 }
 
 func EventEntryHandler(w http.ResponseWriter, r *http.Request) {
-	playerNames, err := getAvailablePlayerNames()
-	if err != nil {
-		http.Error(w, "Error loading players data", http.StatusInternalServerError)
+	s, ok := requestSeries(w, r)
+	if !ok {
 		return
 	}
-
-	templateData := map[string]interface{}{
-		"ActivePage":  "events",
-		"Scheme":      templates.ColorScheme(),
-		"PlayerNames": playerNames,
-	}
-	templates.RenderTemplate(w, "admin_event.tmpl", templateData)
+	renderEventForm(w, r, s, http.StatusOK, "", "", nil, aggregation.ValidationReport{})
 }
 
 func EventEntryPostHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
 	err := r.ParseForm()
 	if err != nil {
 		http.Error(w, "Error parsing form", http.StatusBadRequest)
 		return
 	}
 
-	eventName := r.FormValue("event_name")
-	eventDate := r.FormValue("event_date")
-	if eventName == "" || eventDate == "" {
-		http.Error(w, "Event name and date are required", http.StatusBadRequest)
+	event, message := eventFromForm(r, s)
+	if message != "" {
+		renderEventForm(w, r, s, http.StatusBadRequest, message, "", &event, aggregation.ValidationReport{})
 		return
 	}
-
-	// Parse match results from form
-	matches, err := parseMatchResults(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if report := aggregation.ValidateEvent(event); report.HasErrors() {
+		renderEventForm(w, r, s, http.StatusBadRequest, "The event could not be saved, fix the errors below", "", &event, report)
 		return
 	}
-
-	// Extract unique players from matches
-	playerSet := make(map[string]bool)
-	for _, match := range matches {
-		playerSet[match.Player1] = true
-		playerSet[match.Player2] = true
-	}
-
-	// Create event structure using InputEvent
-	event := aggregation.InputEvent{
-		Name:       eventName,
-		Date:       eventDate,
-		Rounds:     4,
-		PlayerInfo: make(map[string]aggregation.PlayerEventInfo),
-		Matches:    matches,
-	}
-
-	// Initialize player info for all players
-	for player := range playerSet {
-		deckName := r.FormValue(fmt.Sprintf("player_deck_%s", player))
-		event.PlayerInfo[player] = aggregation.PlayerEventInfo{
-			Deck:     deckName,
-			Decklist: "",
-		}
-	}
-
-	// Save the event to a JSON file
-	eventJSON, err := json.MarshalIndent(event, "", "  ")
-	if err != nil {
-		http.Error(w, "Error creating event data", http.StatusInternalServerError)
+	if !eventDateFree(w, r, s, event, "") {
 		return
 	}
 
-	// Create events directory if it doesn't exist
-	if _, err := os.Stat("input/events"); os.IsNotExist(err) {
-		err = os.MkdirAll("input/events", 0755)
-		if err != nil {
-			http.Error(w, "Error creating events directory", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	filePath := fmt.Sprintf("input/events/%s.json", eventDate)
-	err = os.WriteFile(filePath, eventJSON, 0644)
-	if err != nil {
+	// Save the event, keeping a copy of any file it replaces
+	if err := audit.SaveEvent(s.InputDir(), auth.Username(r), audit.ActionCreate, "", event); err != nil {
+		slog.ErrorContext(r.Context(), "Error saving event", "event_date", event.Date, "err", err)
 		http.Error(w, "Error saving event", http.StatusInternalServerError)
 		return
 	}
 
 	// Redirect to admin events page
-	http.Redirect(w, r, "/admin/events", http.StatusSeeOther)
+	http.Redirect(w, r, adminURL(s, "/events"), http.StatusSeeOther)
 }
 
 func EventEditHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
 	// Extract event date from URL path
-	eventDate := r.URL.Path[len("/admin/events/edit/"):]
+	eventDate := r.PathValue("date")
+	if !validEventDate(eventDate) {
+		NotFoundHandler(w, r)
+		return
+	}
 
 	// Read the existing event file
-	filePath := fmt.Sprintf("input/events/%s.json", eventDate)
+	filePath := filepath.Join(s.InputDir(), "events", eventDate+".json")
 	fileContent, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Printf("Error reading event file %s: %v", filePath, err)
+		slog.ErrorContext(r.Context(), "Error reading event file", "path", filePath, "err", err)
 		http.Error(w, "Event not found", http.StatusNotFound)
 		return
 	}
@@ -272,30 +360,27 @@ func EventEditHandler(w http.ResponseWriter, r *http.Request) {
 	var existingEvent aggregation.InputEvent
 	err = json.Unmarshal(fileContent, &existingEvent)
 	if err != nil {
-		log.Printf("Error unmarshalling event data: %v", err)
+		slog.ErrorContext(r.Context(), "Error unmarshalling event data", "err", err)
 		http.Error(w, "Error parsing event data", http.StatusInternalServerError)
 		return
 	}
 
-	playerNames, err := getAvailablePlayerNames()
-	if err != nil {
-		http.Error(w, "Error loading players data", http.StatusInternalServerError)
-		return
-	}
-
-	templateData := map[string]interface{}{
-		"ActivePage":    "events",
-		"Scheme":        templates.ColorScheme(),
-		"PlayerNames":   playerNames,
-		"ExistingEvent": existingEvent,
-		"IsEdit":        true,
-	}
-	templates.RenderTemplate(w, "admin_event.tmpl", templateData)
+	// Show the warnings of the saved event, so they can be fixed
+	renderEventForm(w, r, s, http.StatusOK, "", eventDate, &existingEvent, aggregation.ValidateEvent(existingEvent))
 }
 
 func EventEditPostHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
 	// Extract event date from URL path
-	eventDate := r.URL.Path[len("/admin/events/edit/"):]
+	eventDate := r.PathValue("date")
+	if !validEventDate(eventDate) {
+		NotFoundHandler(w, r)
+		return
+	}
 
 	err := r.ParseForm()
 	if err != nil {
@@ -303,76 +388,41 @@ func EventEditPostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	eventName := r.FormValue("event_name")
-	eventDateForm := r.FormValue("event_date")
-	if eventName == "" || eventDateForm == "" {
-		http.Error(w, "Event name and date are required", http.StatusBadRequest)
+	event, message := eventFromForm(r, s)
+	if message != "" {
+		renderEventForm(w, r, s, http.StatusBadRequest, message, eventDate, &event, aggregation.ValidationReport{})
 		return
 	}
 
-	// Parse match results from form
-	matches, err := parseMatchResults(r)
+	// Keep the decklists linked to players that are still in the event
+	existingEvent, err := audit.CurrentEvent(s.InputDir(), eventDate)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.ErrorContext(r.Context(), "Error reading event", "event_date", eventDate, "err", err)
+		http.Error(w, "Error reading existing event", http.StatusInternalServerError)
 		return
 	}
-
-	// Extract unique players from matches
-	playerSet := make(map[string]bool)
-	for _, match := range matches {
-		playerSet[match.Player1] = true
-		playerSet[match.Player2] = true
-	}
-
-	// Create event structure using InputEvent
-	event := aggregation.InputEvent{
-		Name:       eventName,
-		Date:       eventDateForm,
-		Rounds:     4,
-		PlayerInfo: make(map[string]aggregation.PlayerEventInfo),
-		Matches:    matches,
-	}
-
-	// Initialize player info for all players
-	for player := range playerSet {
-		deckName := r.FormValue(fmt.Sprintf("player_deck_%s", player))
-		event.PlayerInfo[player] = aggregation.PlayerEventInfo{
-			Deck:     deckName,
-			Decklist: "",
+	if existingEvent != nil {
+		for player, info := range event.PlayerInfo {
+			info.Decklist = existingEvent.PlayerInfo[player].Decklist
+			event.PlayerInfo[player] = info
 		}
 	}
 
-	// Save the event to a JSON file
-	eventJSON, err := json.MarshalIndent(event, "", "  ")
-	if err != nil {
-		http.Error(w, "Error creating event data", http.StatusInternalServerError)
+	if report := aggregation.ValidateEvent(event); report.HasErrors() {
+		renderEventForm(w, r, s, http.StatusBadRequest, "The event could not be saved, fix the errors below", eventDate, &event, report)
 		return
 	}
-
-	// Create events directory if it doesn't exist
-	if _, err := os.Stat("input/events"); os.IsNotExist(err) {
-		err = os.MkdirAll("input/events", 0755)
-		if err != nil {
-			http.Error(w, "Error creating events directory", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	// Use the form date for the file path (in case date was changed)
-	newFilePath := fmt.Sprintf("input/events/%s.json", eventDateForm)
-
-	// If the date changed, remove the old file
-	oldFilePath := fmt.Sprintf("input/events/%s.json", eventDate)
-	if oldFilePath != newFilePath {
-		os.Remove(oldFilePath)
+	if event.Date != eventDate && !eventDateFree(w, r, s, event, eventDate) {
+		return
 	}
 
-	err = os.WriteFile(newFilePath, eventJSON, 0644)
-	if err != nil {
+	// Save the event under the form date (in case date was changed), the replaced version is kept in the history
+	if err := audit.SaveEvent(s.InputDir(), auth.Username(r), audit.ActionUpdate, eventDate, event); err != nil {
+		slog.ErrorContext(r.Context(), "Error saving event", "event_date", event.Date, "err", err)
 		http.Error(w, "Error saving event", http.StatusInternalServerError)
 		return
 	}
 
 	// Redirect to admin events page
-	http.Redirect(w, r, "/admin/events", http.StatusSeeOther)
-}
\ No newline at end of file
+	http.Redirect(w, r, adminURL(s, "/events"), http.StatusSeeOther)
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/audit"
+	"premodernonsdagar/internal/auth"
+	"premodernonsdagar/internal/cardmatcher"
+	"premodernonsdagar/internal/decklists"
+	"premodernonsdagar/internal/series"
+	"premodernonsdagar/internal/templates"
+	"premodernonsdagar/internal/utils"
+)
+
+const maxDecklistSize = 64 << 10
+
+// The card databases are large, so each is only loaded once and only when a decklist is edited
+var (
+	cardMatchersMutex sync.Mutex
+	cardMatchers      = make(map[string]*cardmatcher.CardMatcher)
+)
+
+func loadCardMatcher(s series.Series) (*cardmatcher.CardMatcher, error) {
+	cardMatchersMutex.Lock()
+	defer cardMatchersMutex.Unlock()
+
+	if cm, exists := cardMatchers[s.CardDatabase]; exists {
+		return cm, nil
+	}
+	cm, err := cardmatcher.NewCardMatcher(s.CardDatabase)
+	if err != nil {
+		return nil, err
+	}
+	cardMatchers[s.CardDatabase] = cm
+	return cm, nil
+}
+
+type adminDecklistPlayer struct {
+	Name        string
+	Deck        string
+	Decklist    string
+	EditURL     string
+	DecklistURL string
+}
+
+// decklistPage is the series, event and player a decklist page is about
+type decklistPage struct {
+	Series series.Series
+	Event  *aggregation.InputEvent
+	Player string
+}
+
+// loadDecklistEvent loads the event and player from the path, responding with a 404 if either is missing
+func loadDecklistEvent(w http.ResponseWriter, r *http.Request) (decklistPage, bool) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return decklistPage{}, false
+	}
+
+	eventDate := r.PathValue("date")
+	if !validEventDate(eventDate) {
+		NotFoundHandler(w, r)
+		return decklistPage{}, false
+	}
+
+	event, err := audit.CurrentEvent(s.InputDir(), eventDate)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading event", "event_date", eventDate, "err", err)
+		http.Error(w, "Error reading event", http.StatusInternalServerError)
+		return decklistPage{}, false
+	}
+	if event == nil {
+		NotFoundHandler(w, r)
+		return decklistPage{}, false
+	}
+
+	page := decklistPage{Series: s, Event: event}
+	if r.PathValue("player") == "" {
+		return page, true
+	}
+
+	player, found := decklists.FindPlayer(event, r.PathValue("player"))
+	if !found {
+		NotFoundHandler(w, r)
+		return decklistPage{}, false
+	}
+	page.Player = player
+	return page, true
+}
+
+// readDecklistInput returns the uploaded decklist file if there is one, otherwise the pasted text
+func readDecklistInput(r *http.Request) (string, error) {
+	file, _, err := r.FormFile("decklist_file")
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		return r.FormValue("decklist"), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read uploaded decklist: %w", err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxDecklistSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read uploaded decklist: %w", err)
+	}
+	if len(content) > maxDecklistSize {
+		return "", fmt.Errorf("decklist is larger than %d KB", maxDecklistSize>>10)
+	}
+	return string(content), nil
+}
+
+func AdminDecklistsHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := loadDecklistEvent(w, r)
+	if !ok {
+		return
+	}
+	event := page.Event
+
+	players := []adminDecklistPlayer{}
+	for _, name := range decklists.EventPlayers(event) {
+		info := event.PlayerInfo[name]
+		player := adminDecklistPlayer{
+			Name:     name,
+			Deck:     info.Deck,
+			Decklist: info.Decklist,
+			EditURL:  adminURL(page.Series, fmt.Sprintf("/events/%s/decklists/%s", event.Date, utils.Slugify(name))),
+		}
+		if info.Decklist != "" {
+			player.DecklistURL = page.Series.URLPrefix() + "/decklists/" + info.Decklist
+		}
+		players = append(players, player)
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage": "admin",
+		"Scheme":     templates.ColorScheme(),
+		"Event":      event,
+		"Players":    players,
+		"EventsURL":  adminURL(page.Series, "/events"),
+	}
+	templates.RenderTemplate(w, r, "admin_decklists.tmpl", templateData)
+}
+
+func AdminDecklistHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := loadDecklistEvent(w, r)
+	if !ok {
+		return
+	}
+	event, player := page.Event, page.Player
+
+	info := event.PlayerInfo[player]
+	content := ""
+	if info.Decklist != "" {
+		existing, err := os.ReadFile(filepath.Join(decklists.Dir(page.Series), info.Decklist+".txt"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.ErrorContext(r.Context(), "Error reading decklist", "decklist", info.Decklist, "err", err)
+		}
+		content = string(existing)
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage": "admin",
+		"Scheme":     templates.ColorScheme(),
+		"Event":      event,
+		"Player":     player,
+		"Deck":       info.Deck,
+		"Decklist":   content,
+		"FormURL":    adminURL(page.Series, fmt.Sprintf("/events/%s/decklists/%s", event.Date, utils.Slugify(player))),
+		"CSRFToken":  auth.CSRFToken(r),
+		"EventsURL":  adminURL(page.Series, "/events"),
+	}
+	templates.RenderTemplate(w, r, "admin_decklist.tmpl", templateData)
+}
+
+func AdminDecklistPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	content, err := readDecklistInput(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cm, err := loadCardMatcher(s)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading card database", "err", err)
+		http.Error(w, "Error loading card database", http.StatusInternalServerError)
+		return
+	}
+
+	parsed, err := aggregation.ParseDecklist(cm, strings.NewReader(content))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(parsed); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding decklist preview", "err", err)
+	}
+}
+
+func AdminDecklistPostHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := loadDecklistEvent(w, r)
+	if !ok {
+		return
+	}
+	event, player := page.Event, page.Player
+
+	content, err := readDecklistInput(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = decklists.Save(page.Series, event, player, content, r.FormValue("deck"), auth.Username(r))
+	if errors.Is(err, decklists.ErrEmpty) {
+		http.Error(w, "The decklist is empty", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error saving decklist", "event_date", event.Date, "player", player, "err", err)
+		http.Error(w, "Error saving decklist", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, adminURL(page.Series, "/events/"+event.Date+"/decklists"), http.StatusSeeOther)
+}
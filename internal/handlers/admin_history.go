@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"premodernonsdagar/internal/audit"
+	"premodernonsdagar/internal/auth"
+	"premodernonsdagar/internal/templates"
+)
+
+func validEventDate(date string) bool {
+	_, err := time.Parse("2006-01-02", date)
+	return err == nil
+}
+
+func EventHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	eventDate := r.PathValue("date")
+	if !validEventDate(eventDate) {
+		NotFoundHandler(w, r)
+		return
+	}
+
+	entries, err := audit.Entries(s.InputDir(), eventDate)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading audit log", "err", err)
+		http.Error(w, "Error reading history", http.StatusInternalServerError)
+		return
+	}
+
+	versions, err := audit.Versions(s.InputDir(), eventDate)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading versions", "event_date", eventDate, "err", err)
+		http.Error(w, "Error reading history", http.StatusInternalServerError)
+		return
+	}
+
+	current, err := audit.CurrentEvent(s.InputDir(), eventDate)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading event", "event_date", eventDate, "err", err)
+	}
+
+	if current == nil && len(entries) == 0 && len(versions) == 0 {
+		NotFoundHandler(w, r)
+		return
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage": "admin",
+		"Scheme":     templates.ColorScheme(),
+		"Date":       eventDate,
+		"Current":    current,
+		"Entries":    entries,
+		"Versions":   versions,
+		"CSRFToken":  auth.CSRFToken(r),
+		"EventsURL":  adminURL(s, "/events"),
+	}
+	templates.RenderTemplate(w, r, "admin_event_history.tmpl", templateData)
+}
+
+func EventRestorePostHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	eventDate := r.PathValue("date")
+	if !validEventDate(eventDate) {
+		NotFoundHandler(w, r)
+		return
+	}
+
+	version, err := audit.FindVersion(s.InputDir(), eventDate, r.PathValue("version"))
+	if errors.Is(err, audit.ErrVersionNotFound) {
+		NotFoundHandler(w, r)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading versions", "event_date", eventDate, "err", err)
+		http.Error(w, "Error reading history", http.StatusInternalServerError)
+		return
+	}
+
+	if err := audit.SaveEvent(s.InputDir(), auth.Username(r), audit.ActionRestore, "", version.Event); err != nil {
+		slog.ErrorContext(r.Context(), "Error restoring version", "event_date", eventDate, "version", version.ID, "err", err)
+		http.Error(w, "Error restoring event", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, adminURL(s, fmt.Sprintf("/events/%s/history", version.Event.Date)), http.StatusSeeOther)
+}
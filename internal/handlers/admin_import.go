@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/audit"
+	"premodernonsdagar/internal/auth"
+	"premodernonsdagar/internal/importer"
+	"premodernonsdagar/internal/series"
+	"premodernonsdagar/internal/templates"
+)
+
+const maxImportSize = 1 << 20
+
+type importForm struct {
+	Format string
+	Name   string
+	Date   string
+	Export string
+}
+
+// importPreview is the event read from an export, as it would be saved
+type importPreview struct {
+	Result *importer.Result
+	Names  []importer.NameMatch
+	Event  aggregation.InputEvent
+	Report aggregation.ValidationReport
+}
+
+func readImportForm(r *http.Request) (importForm, error) {
+	form := importForm{
+		Format: r.FormValue("format"),
+		Name:   strings.TrimSpace(r.FormValue("event_name")),
+		Date:   strings.TrimSpace(r.FormValue("event_date")),
+		Export: r.FormValue("export"),
+	}
+
+	file, _, err := r.FormFile("export_file")
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		return form, nil
+	}
+	if err != nil {
+		return form, fmt.Errorf("failed to read uploaded export: %w", err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxImportSize+1))
+	if err != nil {
+		return form, fmt.Errorf("failed to read uploaded export: %w", err)
+	}
+	if len(content) > maxImportSize {
+		return form, fmt.Errorf("export is larger than %d KB", maxImportSize>>10)
+	}
+	form.Export = string(content)
+	return form, nil
+}
+
+// previewImport reads the export, naming the players as chosen in the preview or else as suggested
+func previewImport(form importForm, chosen map[string]string) (*importPreview, error) {
+	if form.Name == "" || !validEventDate(form.Date) {
+		return nil, fmt.Errorf("an event name and a date written as YYYY-MM-DD are required")
+	}
+	if len(form.Export) > maxImportSize {
+		return nil, fmt.Errorf("export is larger than %d KB", maxImportSize>>10)
+	}
+
+	result, err := importer.Parse(form.Format, []byte(form.Export))
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := getAvailablePlayerNames()
+	if err != nil {
+		slog.Error("Error reading players", "err", err)
+	}
+	registry, err := aggregation.LoadPlayerRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load player registry: %w", err)
+	}
+
+	names := importer.MatchNames(result.Players, known, registry)
+	players := make(map[string]string, len(names))
+	for i, name := range names {
+		if player := strings.Join(strings.Fields(chosen[name.Export]), " "); player != "" {
+			names[i].Player = player
+		}
+		players[name.Export] = names[i].Player
+	}
+
+	event := result.Event(form.Name, form.Date, players)
+	return &importPreview{
+		Result: result,
+		Names:  names,
+		Event:  event,
+		Report: aggregation.ValidateEvent(event),
+	}, nil
+}
+
+// chosenPlayers reads the players picked for the exported names in the preview
+func chosenPlayers(r *http.Request) map[string]string {
+	chosen := make(map[string]string)
+	exported, players := r.Form["export_name"], r.Form["player_name"]
+	for i := range min(len(exported), len(players)) {
+		chosen[exported[i]] = players[i]
+	}
+	return chosen
+}
+
+func renderImportPage(w http.ResponseWriter, r *http.Request, s series.Series, status int, message string, form importForm, preview *importPreview) {
+	playerNames, err := getAvailablePlayerNames()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading players", "err", err)
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage":  "admin",
+		"Scheme":      templates.ColorScheme(),
+		"Formats":     importer.Formats,
+		"Form":        form,
+		"Preview":     preview,
+		"Error":       message,
+		"PlayerNames": playerNames,
+		"CSRFToken":   auth.CSRFToken(r),
+		"EventsURL":   adminURL(s, "/events"),
+	}
+	templates.RenderTemplateStatus(w, r, status, "admin_import.tmpl", templateData)
+}
+
+func AdminImportHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+	renderImportPage(w, r, s, http.StatusOK, "", importForm{Format: importer.FormatEventLink}, nil)
+}
+
+func AdminImportPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	form, err := readImportForm(r)
+	if err != nil {
+		renderImportPage(w, r, s, http.StatusBadRequest, err.Error(), form, nil)
+		return
+	}
+
+	preview, err := previewImport(form, chosenPlayers(r))
+	if err != nil {
+		renderImportPage(w, r, s, http.StatusBadRequest, err.Error(), form, nil)
+		return
+	}
+	renderImportPage(w, r, s, http.StatusOK, "", form, preview)
+}
+
+// AdminImportSavePostHandler saves the previewed event, with the players named as chosen in the preview
+func AdminImportSavePostHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	form, err := readImportForm(r)
+	if err != nil {
+		renderImportPage(w, r, s, http.StatusBadRequest, err.Error(), form, nil)
+		return
+	}
+
+	preview, err := previewImport(form, chosenPlayers(r))
+	if err != nil {
+		renderImportPage(w, r, s, http.StatusBadRequest, err.Error(), form, nil)
+		return
+	}
+	if preview.Report.HasErrors() {
+		renderImportPage(w, r, s, http.StatusBadRequest, "Fix the errors below before saving", form, preview)
+		return
+	}
+
+	existing, err := audit.CurrentEvent(s.InputDir(), form.Date)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading event", "event_date", form.Date, "err", err)
+		http.Error(w, "Error reading event", http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		renderImportPage(w, r, s, http.StatusConflict, "There is already an event on "+form.Date+", edit it instead or pick another date", form, preview)
+		return
+	}
+
+	if err := audit.SaveEvent(s.InputDir(), auth.Username(r), audit.ActionImport, "", preview.Event); err != nil {
+		slog.ErrorContext(r.Context(), "Error saving event", "event_date", form.Date, "err", err)
+		http.Error(w, "Error saving event", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, adminURL(s, "/events/"+form.Date+"/edit"), http.StatusSeeOther)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/auth"
+	"premodernonsdagar/internal/templates"
+)
+
+// Serializes changes to the player registry file
+var playerRegistryMutex sync.Mutex
+
+func AdminPlayersHandler(w http.ResponseWriter, r *http.Request) {
+	registry, err := aggregation.LoadPlayerRegistry()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading player registry", "err", err)
+		http.Error(w, "Error loading player registry", http.StatusInternalServerError)
+		return
+	}
+
+	directory, err := aggregation.PlayerDirectory(registry)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing players", "err", err)
+		http.Error(w, "Error listing players", http.StatusInternalServerError)
+		return
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage": "admin",
+		"Scheme":     templates.ColorScheme(),
+		"Players":    directory,
+		"Error":      r.URL.Query().Get("error"),
+		"CSRFToken":  auth.CSRFToken(r),
+	}
+	templates.RenderTemplate(w, r, "admin_players.tmpl", templateData)
+}
+
+// updatePlayerRegistry applies a change to the registry and saves it, redirecting back to the player list
+func updatePlayerRegistry(w http.ResponseWriter, r *http.Request, update func(*aggregation.PlayerRegistry, []aggregation.PlayerDirectoryEntry, string) error) {
+	playerRegistryMutex.Lock()
+	defer playerRegistryMutex.Unlock()
+
+	registry, err := aggregation.LoadPlayerRegistry()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading player registry", "err", err)
+		http.Error(w, "Error loading player registry", http.StatusInternalServerError)
+		return
+	}
+
+	directory, err := aggregation.PlayerDirectory(registry)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing players", "err", err)
+		http.Error(w, "Error listing players", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := registry.RegisterFromDirectory(directory, r.PathValue("id"))
+	if errors.Is(err, aggregation.ErrPlayerNotFound) {
+		NotFoundHandler(w, r)
+		return
+	}
+	if err == nil {
+		err = update(registry, directory, id)
+	}
+	if err != nil {
+		http.Redirect(w, r, "/admin/players?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	if err := registry.Save(); err != nil {
+		slog.ErrorContext(r.Context(), "Error saving player registry", "err", err)
+		http.Error(w, "Error saving player registry", http.StatusInternalServerError)
+		return
+	}
+
+	if targetID, merged := registry.MergedInto(id); merged {
+		id = targetID
+	}
+	http.Redirect(w, r, "/admin/players#"+id, http.StatusSeeOther)
+}
+
+func AdminPlayerRenamePostHandler(w http.ResponseWriter, r *http.Request) {
+	updatePlayerRegistry(w, r, func(registry *aggregation.PlayerRegistry, _ []aggregation.PlayerDirectoryEntry, id string) error {
+		return registry.Rename(id, r.FormValue("name"))
+	})
+}
+
+func AdminPlayerAliasPostHandler(w http.ResponseWriter, r *http.Request) {
+	updatePlayerRegistry(w, r, func(registry *aggregation.PlayerRegistry, _ []aggregation.PlayerDirectoryEntry, id string) error {
+		return registry.AddAlias(id, r.FormValue("alias"))
+	})
+}
+
+func AdminPlayerAliasRemovePostHandler(w http.ResponseWriter, r *http.Request) {
+	updatePlayerRegistry(w, r, func(registry *aggregation.PlayerRegistry, _ []aggregation.PlayerDirectoryEntry, id string) error {
+		return registry.RemoveAlias(id, r.FormValue("alias"))
+	})
+}
+
+func AdminPlayerMergePostHandler(w http.ResponseWriter, r *http.Request) {
+	updatePlayerRegistry(w, r, func(registry *aggregation.PlayerRegistry, directory []aggregation.PlayerDirectoryEntry, id string) error {
+		var source, target aggregation.PlayerDirectoryEntry
+		for _, entry := range directory {
+			if entry.ID == r.PathValue("id") {
+				source = entry
+			}
+			if entry.ID == r.FormValue("target") {
+				target = entry
+			}
+		}
+		if target.ID == "" {
+			return fmt.Errorf("choose a player to merge into")
+		}
+
+		// Two names at the same event are two different people
+		shared := []string{}
+		for _, date := range source.Dates {
+			if slices.Contains(target.Dates, date) {
+				shared = append(shared, date)
+			}
+		}
+		if len(shared) > 0 {
+			return fmt.Errorf("%s and %s both played on %s and cannot be the same player", source.Name, target.Name, strings.Join(shared, ", "))
+		}
+
+		targetID, err := registry.RegisterFromDirectory(directory, target.ID)
+		if err != nil {
+			return err
+		}
+		return registry.Merge(id, targetID)
+	})
+}
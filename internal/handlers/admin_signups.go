@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/audit"
+	"premodernonsdagar/internal/auth"
+	"premodernonsdagar/internal/decklists"
+	"premodernonsdagar/internal/signup"
+	"premodernonsdagar/internal/templates"
+)
+
+func AdminSignupsHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	eventDate := r.URL.Query().Get("date")
+	if eventDate == "" {
+		if next, scheduled := s.NextEvent(time.Now()); scheduled {
+			eventDate = next.Date.Format("2006-01-02")
+		}
+	}
+	if !validEventDate(eventDate) {
+		NotFoundHandler(w, r)
+		return
+	}
+
+	signups, err := signup.List(s.InputDir(), eventDate)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading sign-ups", "err", err)
+		http.Error(w, "Error reading sign-ups", http.StatusInternalServerError)
+		return
+	}
+
+	event, err := audit.CurrentEvent(s.InputDir(), eventDate)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading event", "event_date", eventDate, "err", err)
+		http.Error(w, "Error reading event", http.StatusInternalServerError)
+		return
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage":    "admin",
+		"Scheme":        templates.ColorScheme(),
+		"Date":          eventDate,
+		"Signups":       signups,
+		"Pairings":      signup.RandomPairings(signups),
+		"EventExists":   event != nil,
+		"CSRFToken":     auth.CSRFToken(r),
+		"EventsURL":     adminURL(s, "/events"),
+		"SignupsURL":    adminURL(s, "/signups"),
+		"SignupPageURL": s.URLPrefix() + "/signup",
+	}
+	templates.RenderTemplate(w, r, "admin_signups.tmpl", templateData)
+}
+
+func AdminSignupRemovePostHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	eventDate := r.PathValue("date")
+	if !validEventDate(eventDate) {
+		NotFoundHandler(w, r)
+		return
+	}
+
+	err := signup.Remove(s.InputDir(), eventDate, r.FormValue("player"))
+	if errors.Is(err, signup.ErrSignupNotFound) {
+		NotFoundHandler(w, r)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error removing sign-up", "err", err)
+		http.Error(w, "Error removing sign-up", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, adminURL(s, "/signups?date="+eventDate), http.StatusSeeOther)
+}
+
+// AdminSignupsEventPostHandler creates the event from the sign-ups, or adds the signed up players to it if it exists
+func AdminSignupsEventPostHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	eventDate := r.PathValue("date")
+	if !validEventDate(eventDate) {
+		NotFoundHandler(w, r)
+		return
+	}
+
+	signups, err := signup.List(s.InputDir(), eventDate)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading sign-ups", "err", err)
+		http.Error(w, "Error reading sign-ups", http.StatusInternalServerError)
+		return
+	}
+	if len(signups) == 0 {
+		http.Error(w, "Nobody has signed up for this event", http.StatusBadRequest)
+		return
+	}
+
+	event, err := audit.CurrentEvent(s.InputDir(), eventDate)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading event", "event_date", eventDate, "err", err)
+		http.Error(w, "Error reading event", http.StatusInternalServerError)
+		return
+	}
+
+	action := audit.ActionUpdate
+	if event == nil {
+		action = audit.ActionCreate
+		name := s.Name + " " + eventDate
+		if s.Main() {
+			name = "Onsdagstävling " + eventDate
+		}
+		event = &aggregation.InputEvent{
+			Name:    name,
+			Date:    eventDate,
+			Rounds:  s.Rounds,
+			Matches: []aggregation.Match{},
+		}
+	}
+	if event.PlayerInfo == nil {
+		event.PlayerInfo = make(map[string]aggregation.PlayerEventInfo)
+	}
+
+	for _, su := range signups {
+		info := event.PlayerInfo[su.Player]
+		if info.Deck == "" {
+			info.Deck = su.Deck
+		}
+
+		// Info entered by the organizer wins over the sign-up
+		if info.Decklist == "" && su.Decklist != "" {
+			baseName := decklists.BaseName(eventDate, su.Player)
+			if err := os.MkdirAll(decklists.Dir(s), 0755); err != nil {
+				http.Error(w, "Error creating decklists directory", http.StatusInternalServerError)
+				return
+			}
+			if err := os.WriteFile(filepath.Join(decklists.Dir(s), baseName+".txt"), []byte(su.Decklist+"\n"), 0644); err != nil {
+				slog.ErrorContext(r.Context(), "Error saving decklist", "decklist", baseName, "err", err)
+				http.Error(w, "Error saving decklist", http.StatusInternalServerError)
+				return
+			}
+			info.Decklist = baseName
+		}
+
+		event.PlayerInfo[su.Player] = info
+	}
+
+	if err := audit.SaveEvent(s.InputDir(), auth.Username(r), action, "", *event); err != nil {
+		slog.ErrorContext(r.Context(), "Error saving event", "event_date", eventDate, "err", err)
+		http.Error(w, "Error saving event", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, adminURL(s, fmt.Sprintf("/events/%s/edit", eventDate)), http.StatusSeeOther)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/audit"
+)
+
+func postEventForm(handler http.HandlerFunc, editDate, name, date string) *httptest.ResponseRecorder {
+	form := url.Values{
+		"event_name":           {name},
+		"event_date":           {date},
+		"matches[0][player_1]": {"Alice"},
+		"matches[0][player_2]": {"Bob"},
+		"matches[0][result]":   {"2-1"},
+	}
+	r := httptest.NewRequest(http.MethodPost, "/admin/events", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetPathValue("date", editDate)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestEventSaveKeepsExistingEvent(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	for _, date := range []string{"2025-08-19", "2025-08-20"} {
+		event := aggregation.InputEvent{
+			Name:    "Onsdag " + date,
+			Date:    date,
+			Matches: []aggregation.Match{{Player1: "Alice", Player2: "Bob", Result: "2-0"}},
+		}
+		if err := audit.SaveEvent("input", "alice", audit.ActionCreate, "", event); err != nil {
+			t.Fatalf("SaveEvent returned error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		editDate string
+	}{
+		{"create", EventEntryPostHandler, ""},
+		{"move", EventEditPostHandler, "2025-08-19"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postEventForm(tt.handler, tt.editDate, "Replacement", "2025-08-20")
+			if w.Code != http.StatusConflict {
+				t.Fatalf("Expected status %d, got %d", http.StatusConflict, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), "There is already an event on 2025-08-20") {
+				t.Error("Expected the form to explain the conflict")
+			}
+
+			for _, date := range []string{"2025-08-19", "2025-08-20"} {
+				event, err := audit.CurrentEvent("input", date)
+				if err != nil {
+					t.Fatalf("CurrentEvent returned error: %v", err)
+				}
+				if event == nil || event.Name != "Onsdag "+date {
+					t.Errorf("Expected the event on %s to be kept, got %+v", date, event)
+				}
+			}
+		})
+	}
+
+	// Saving an event on its own date is an edit, not a conflict
+	w := postEventForm(EventEditPostHandler, "2025-08-20", "Edited", "2025-08-20")
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, w.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"premodernonsdagar/internal/auth"
+	"premodernonsdagar/internal/templates"
+)
+
+// loginRedirectTarget only allows redirecting back into the admin section
+func loginRedirectTarget(next string) string {
+	if !strings.HasPrefix(next, "/admin/") || strings.HasPrefix(next, "/admin/login") {
+		return "/admin/events"
+	}
+	return next
+}
+
+func renderLoginPage(w http.ResponseWriter, r *http.Request, authenticator *auth.Authenticator, status int, errorMessage string) {
+	csrfToken, err := authenticator.LoginCSRFToken(w, r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating login CSRF token", "err", err)
+		http.Error(w, "Error creating session", http.StatusInternalServerError)
+		return
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage": "admin",
+		"Scheme":     templates.ColorScheme(),
+		"CSRFToken":  csrfToken,
+		"Next":       loginRedirectTarget(r.FormValue("next")),
+		"Error":      errorMessage,
+	}
+	templates.RenderTemplateStatus(w, r, status, "admin_login.tmpl", templateData)
+}
+
+func AdminLoginHandler(authenticator *auth.Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authenticator.Open() {
+			http.Redirect(w, r, "/admin/events", http.StatusSeeOther)
+			return
+		}
+		renderLoginPage(w, r, authenticator, http.StatusOK, "")
+	}
+}
+
+func AdminLoginPostHandler(authenticator *auth.Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authenticator.Open() {
+			http.Redirect(w, r, "/admin/events", http.StatusSeeOther)
+			return
+		}
+
+		username := strings.TrimSpace(r.FormValue("username"))
+		err := authenticator.Login(w, r, username, r.FormValue("password"))
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			slog.WarnContext(r.Context(), "Failed admin login", "user", username, "remote_addr", authenticator.ClientAddress(r))
+			renderLoginPage(w, r, authenticator, http.StatusUnauthorized, "Invalid username or password")
+			return
+		}
+		if errors.Is(err, auth.ErrTooManyAttempts) {
+			slog.WarnContext(r.Context(), "Admin login refused after too many failures", "user", username, "remote_addr", authenticator.ClientAddress(r))
+			renderLoginPage(w, r, authenticator, http.StatusTooManyRequests, "Too many failed logins, please try again later")
+			return
+		}
+		if errors.Is(err, auth.ErrInvalidCSRFToken) {
+			renderLoginPage(w, r, authenticator, http.StatusForbidden, "Your session expired, please try again")
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error logging in", "err", err)
+			http.Error(w, "Error logging in", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, loginRedirectTarget(r.FormValue("next")), http.StatusSeeOther)
+	}
+}
+
+func AdminLogoutHandler(authenticator *auth.Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authenticator.Logout(w, r)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	}
+}
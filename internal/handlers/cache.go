@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// The time the served stats were last aggregated or swapped, the version of the pages built from them
+var dataModified atomic.Value
+
+func init() {
+	dataChanged()
+}
+
+func dataChanged() {
+	dataModified.Store(time.Now().Truncate(time.Second))
+}
+
+// lastModified is when the pages built from the stats last changed, at the latest at midnight since they show the next event
+func lastModified(now time.Time) time.Time {
+	modified := dataModified.Load().(time.Time)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if midnight.After(modified) {
+		return midnight
+	}
+	return modified
+}
+
+// notModified reports whether the copy the client already has is still current
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.After(since)
+}
+
+// cached answers the conditional requests for a page built from the stats with 304 when the stats are unchanged,
+// without reading or rendering anything
+func cached(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		modified := lastModified(time.Now())
+		etag := fmt.Sprintf(`W/"%x"`, modified.Unix())
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+		w.Header().Set("Cache-Control", "no-cache")
+		if notModified(r, etag, modified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next(w, r)
+	}
+}
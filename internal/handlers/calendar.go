@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"premodernonsdagar/internal/calendar"
+	"premodernonsdagar/internal/series"
+)
+
+const (
+	calendarDaysBack   = 30
+	defaultEventLength = 4 * time.Hour
+)
+
+// atTime returns the wall clock time on the day, false for an empty or malformed time
+func atTime(day time.Time, clock string) (time.Time, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC), true
+}
+
+func calendarEvent(s series.Series, o series.Occurrence) calendar.Event {
+	date := o.Date.Format("2006-01-02")
+	event := calendar.Event{
+		UID:       fmt.Sprintf("%s-%s@premodernonsdagar", s.ID, cmp.Or(o.MovedFrom, date)),
+		Summary:   cmp.Or(o.Name, s.Name),
+		Location:  o.Location,
+		Cancelled: o.Cancelled,
+	}
+	if o.Name != "" {
+		event.UID = fmt.Sprintf("%s-special-%s@premodernonsdagar", s.ID, date)
+	}
+
+	switch {
+	case o.Cancelled:
+		event.Description = cmp.Or(o.Note, "Cancelled")
+	case o.MovedFrom != "" && o.Note != "":
+		event.Description = fmt.Sprintf("Moved from %s: %s", o.MovedFrom, o.Note)
+	case o.MovedFrom != "":
+		event.Description = "Moved from " + o.MovedFrom
+	}
+
+	start, timed := atTime(o.Date, o.StartTime)
+	if !timed {
+		event.AllDay = true
+		event.Start, event.End = o.Date, o.Date.AddDate(0, 0, 1)
+		return event
+	}
+
+	event.Start, event.End = start, start.Add(defaultEventLength)
+	if end, ok := atTime(o.Date, o.EndTime); ok {
+		if end.Before(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		event.End = end
+	}
+	return event
+}
+
+func CalendarHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	occurrences := s.Occurrences(now.AddDate(0, 0, -calendarDaysBack), now.AddDate(1, 0, 0))
+	events := make([]calendar.Event, 0, len(occurrences))
+	for _, o := range occurrences {
+		events = append(events, calendarEvent(s, o))
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if err := calendar.Write(w, s.Name, events); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing calendar", "err", err)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"premodernonsdagar/internal/aggregation"
+)
+
+// ExportHandler serves the CSV exports of a series, and the zip with all of them, as downloads
+func ExportHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	name := r.PathValue("file")
+	if name != aggregation.ExportsZip && !slices.Contains(aggregation.ExportFiles, name) {
+		NotFoundHandler(w, r)
+		return
+	}
+
+	file, err := os.Open(seriesFile(s, "exports", name))
+	if err != nil {
+		NotFoundHandler(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "Error reading export", http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if strings.HasSuffix(name, ".zip") {
+		contentType = "application/zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+s.ID+"-"+name+`"`)
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
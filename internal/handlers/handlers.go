@@ -2,46 +2,95 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"io/fs"
+	"log/slog"
 	"net/http"
-	"net/url"
-	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/images"
+	"premodernonsdagar/internal/series"
 	"premodernonsdagar/internal/templates"
 	"premodernonsdagar/internal/utils"
 )
 
+const (
+	upcomingDays      = 42
+	maxUpcomingEvents = 3
+)
+
+type seriesOverview struct {
+	Name      string
+	Format    string
+	URL       string
+	NextEvent string
+}
+
 func IndexHandler(w http.ResponseWriter, r *http.Request) {
-	nextEvent := utils.NextEvent(time.Now())
-	weekNumber := utils.SwedishWeekNumber(nextEvent)
+	allSeries, err := loadSeries()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading series", "err", err)
+		http.Error(w, "Error loading series", http.StatusInternalServerError)
+		return
+	}
 
-	eventString := nextEvent.Format("2006-01-02")
-	if nextEvent.Format("2006-01-02") == time.Now().Format("2006-01-02") {
+	mainSeries, _ := series.Find(allSeries, "")
+	nextEvent, scheduled := mainSeries.NextEvent(time.Now())
+
+	// A series without a schedule has no next event to show, nor a week for it
+	eventString := ""
+	weekNumber := 0
+	if scheduled {
+		eventString = nextEvent.Date.Format("2006-01-02")
+		weekNumber = utils.SwedishWeekNumber(nextEvent.Date)
+	}
+	if scheduled && nextEvent.Date.Format("2006-01-02") == time.Now().Format("2006-01-02") {
 		eventString = "Today!"
 	}
 
+	// The events after the next one, and the regular events that are cancelled
+	upcoming := []series.Occurrence{}
+	if scheduled {
+		for _, o := range mainSeries.Occurrences(time.Now(), time.Now().AddDate(0, 0, upcomingDays)) {
+			if !o.Date.Equal(nextEvent.Date) && len(upcoming) < maxUpcomingEvents {
+				upcoming = append(upcoming, o)
+			}
+		}
+	}
+
+	otherSeries := []seriesOverview{}
+	for _, s := range allSeries {
+		if s.Main() {
+			continue
+		}
+		overview := seriesOverview{Name: s.Name, Format: s.Format, URL: s.URLPrefix() + "/events"}
+		if next, scheduled := s.NextEvent(time.Now()); scheduled {
+			overview.NextEvent = next.Date.Format("2006-01-02")
+		}
+		otherSeries = append(otherSeries, overview)
+	}
+
 	templateData := map[string]interface{}{
 		"ActivePage":          "index",
 		"NextEventDate":       eventString,
 		"NextEventWeekNumber": weekNumber,
+		"NextEvent":           nextEvent,
+		"Upcoming":            upcoming,
+		"OtherSeries":         otherSeries,
 		"Scheme":              templates.ColorScheme(),
 	}
-	templates.RenderTemplate(w, "index.tmpl", templateData)
+	templates.RenderTemplate(w, r, "index.tmpl", templateData)
 }
 
 func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotFound)
 	templateData := map[string]interface{}{
 		"ActivePage": "404",
 		"Scheme":     templates.ColorScheme(),
 	}
-	templates.RenderTemplate(w, "404.tmpl", templateData)
+	templates.RenderTemplateStatus(w, r, http.StatusNotFound, "404.tmpl", templateData)
 }
 
 func AboutHandler(w http.ResponseWriter, r *http.Request) {
@@ -50,11 +99,16 @@ func AboutHandler(w http.ResponseWriter, r *http.Request) {
 		"Scheme":     templates.ColorScheme(),
 		"Rules":      templates.Rules(),
 	}
-	templates.RenderTemplate(w, "about.tmpl", templateData)
+	templates.RenderTemplate(w, r, "about.tmpl", templateData)
 }
 
 func EventsHandler(w http.ResponseWriter, r *http.Request) {
-	fileContent, err := os.ReadFile("files/lists/events.json")
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	fileContent, err := readSeriesFile(s, "lists", "events.json")
 	if err != nil {
 		http.Error(w, "Error reading events file", http.StatusInternalServerError)
 		return
@@ -63,7 +117,7 @@ func EventsHandler(w http.ResponseWriter, r *http.Request) {
 	var eventsData aggregation.EventListStats
 	err = json.Unmarshal(fileContent, &eventsData)
 	if err != nil {
-		log.Printf("Error unmarshalling events data: %v", err)
+		slog.ErrorContext(r.Context(), "Error unmarshalling events data", "err", err)
 		http.Error(w, "Error loading events data", http.StatusInternalServerError)
 		return
 	}
@@ -89,21 +143,27 @@ func EventsHandler(w http.ResponseWriter, r *http.Request) {
 	templateData := map[string]interface{}{
 		"ActivePage": "events",
 		"Scheme":     templates.ColorScheme(),
+		"Series":     s,
 		"Stats":      stats,
 		"Events":     eventsData.Events,
 	}
 
-	templates.RenderTemplate(w, "events.tmpl", templateData)
+	templates.RenderTemplate(w, r, "events.tmpl", templateData)
 }
 
 func EventDetailHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract event ID from URL path
-	eventID := r.URL.Path[len("/events/"):]
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
 
-	filePath := "files/events/" + eventID + ".json"
-	fileContent, err := os.ReadFile(filePath)
+	fileContent, err := readSeriesFile(s, "events", r.PathValue("id")+".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		NotFoundHandler(w, r)
+		return
+	}
 	if err != nil {
-		log.Printf("Error reading event file %s: %v", filePath, err)
+		slog.ErrorContext(r.Context(), "Error reading event file", "err", err)
 		http.Error(w, "Error reading events file", http.StatusInternalServerError)
 		return
 	}
@@ -111,7 +171,7 @@ func EventDetailHandler(w http.ResponseWriter, r *http.Request) {
 	var eventsData aggregation.Event
 	err = json.Unmarshal(fileContent, &eventsData)
 	if err != nil {
-		log.Printf("Error unmarshalling events data: %v", err)
+		slog.ErrorContext(r.Context(), "Error unmarshalling events data", "err", err)
 		http.Error(w, "Error loading events data", http.StatusInternalServerError)
 		return
 	}
@@ -119,13 +179,19 @@ func EventDetailHandler(w http.ResponseWriter, r *http.Request) {
 	templateData := map[string]interface{}{
 		"ActivePage": "events",
 		"Scheme":     templates.ColorScheme(),
+		"Series":     s,
 		"Event":      eventsData,
 	}
-	templates.RenderTemplate(w, "event.tmpl", templateData)
+	templates.RenderTemplate(w, r, "event.tmpl", templateData)
 }
 
 func PlayersHandler(w http.ResponseWriter, r *http.Request) {
-	fileContent, err := os.ReadFile("files/lists/players.json")
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	fileContent, err := readSeriesFile(s, "lists", "players.json")
 	if err != nil {
 		http.Error(w, "Error reading players file", http.StatusInternalServerError)
 		return
@@ -140,18 +206,28 @@ func PlayersHandler(w http.ResponseWriter, r *http.Request) {
 	templateData := map[string]interface{}{
 		"ActivePage": "players",
 		"Scheme":     templates.ColorScheme(),
+		"Series":     s,
 		"Players":    playersData,
 	}
-	templates.RenderTemplate(w, "players.tmpl", templateData)
+	templates.RenderTemplate(w, r, "players.tmpl", templateData)
 }
 
 func PlayerDetailHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract player ID from URL path
-	playerID := r.URL.Path[len("/players/"):]
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
 
-	filePath := "files/players/" + playerID + ".json"
-	fileContent, err := os.ReadFile(filePath)
+	playerID := r.PathValue("id")
+	fileContent, err := readSeriesFile(s, "players", playerID+".json")
 	if err != nil {
+		// Players that were merged into another player keep their old links working
+		if registry, err := aggregation.LoadPlayerRegistry(); err == nil {
+			if targetID, merged := registry.MergedInto(playerID); merged {
+				http.Redirect(w, r, s.URLPrefix()+"/players/"+targetID, http.StatusMovedPermanently)
+				return
+			}
+		}
 		NotFoundHandler(w, r)
 		return
 	}
@@ -166,13 +242,19 @@ func PlayerDetailHandler(w http.ResponseWriter, r *http.Request) {
 	templateData := map[string]interface{}{
 		"ActivePage": "players",
 		"Scheme":     templates.ColorScheme(),
+		"Series":     s,
 		"Player":     playerData,
 	}
-	templates.RenderTemplate(w, "player.tmpl", templateData)
+	templates.RenderTemplate(w, r, "player.tmpl", templateData)
 }
 
 func LeaderboardsHandler(w http.ResponseWriter, r *http.Request) {
-	fileContent, err := os.ReadFile("files/lists/leaderboards/current.json")
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	fileContent, err := readSeriesFile(s, "lists", "leaderboards", "current.json")
 	if err != nil {
 		http.Error(w, "Error reading leaderboards file", http.StatusInternalServerError)
 		return
@@ -195,19 +277,26 @@ func LeaderboardsHandler(w http.ResponseWriter, r *http.Request) {
 	templateData := map[string]interface{}{
 		"ActivePage":   "leaderboards",
 		"Scheme":       templates.ColorScheme(),
+		"Series":       s,
 		"Leaderboards": leaderboardsData.Leaderboards,
 		"ShowCount":    showCount,
 		"Season":       leaderboardsData.Season,
 		"Seasons":      leaderboardsData.AllSeasons,
 	}
-	templates.RenderTemplate(w, "leaderboards.tmpl", templateData)
+	templates.RenderTemplate(w, r, "leaderboards.tmpl", templateData)
 }
 
 func LeaderboardsDetailHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract season from URL path
-	season := r.URL.Path[len("/leaderboards/"):]
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
 
-	fileContent, err := os.ReadFile("files/lists/leaderboards/" + season + ".json")
+	fileContent, err := readSeriesFile(s, "lists", "leaderboards", r.PathValue("season")+".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		NotFoundHandler(w, r)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Error reading leaderboards file", http.StatusInternalServerError)
 		return
@@ -230,95 +319,150 @@ func LeaderboardsDetailHandler(w http.ResponseWriter, r *http.Request) {
 	templateData := map[string]interface{}{
 		"ActivePage":   "leaderboards",
 		"Scheme":       templates.ColorScheme(),
+		"Series":       s,
 		"Leaderboards": leaderboardsData.Leaderboards,
 		"ShowCount":    showCount,
 		"Season":       leaderboardsData.Season,
 		"Seasons":      leaderboardsData.AllSeasons,
 	}
-	templates.RenderTemplate(w, "leaderboards.tmpl", templateData)
+	templates.RenderTemplate(w, r, "leaderboards.tmpl", templateData)
 }
 
-func DecklistHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract decklist ID from URL path
-	decklistID := r.URL.Path[len("/decklists/"):]
+// PredictionsHandler shows how well the ratings predicted the match results
+func PredictionsHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
 
-	filePath := "files/decklists/" + decklistID + ".json"
-	fileContent, err := os.ReadFile(filePath)
+	fileContent, err := readSeriesFile(s, "lists", "predictions.json")
+	if errors.Is(err, fs.ErrNotExist) {
+		NotFoundHandler(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error reading predictions file", http.StatusInternalServerError)
+		return
+	}
+
+	var predictions aggregation.PredictionsOverview
+	if err := json.Unmarshal(fileContent, &predictions); err != nil {
+		http.Error(w, "Error loading predictions data", http.StatusInternalServerError)
+		return
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage":  "leaderboards",
+		"Scheme":      templates.ColorScheme(),
+		"Series":      s,
+		"Predictions": predictions,
+	}
+	templates.RenderTemplate(w, r, "predictions.tmpl", templateData)
+}
+
+func AchievementsHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	fileContent, err := readSeriesFile(s, "lists", "achievements.json")
+	if err != nil {
+		http.Error(w, "Error reading achievements file", http.StatusInternalServerError)
+		return
+	}
+
+	var achievementsData []aggregation.AchievementOverview
+	err = json.Unmarshal(fileContent, &achievementsData)
+	if err != nil {
+		http.Error(w, "Error loading achievements data", http.StatusInternalServerError)
+		return
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage":   "players",
+		"Scheme":       templates.ColorScheme(),
+		"Series":       s,
+		"Achievements": achievementsData,
+	}
+	templates.RenderTemplate(w, r, "achievements.tmpl", templateData)
+}
+
+func SeasonStandingsHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+
+	fileContent, err := readSeriesFile(s, "lists", "standings", r.PathValue("season")+".json")
 	if err != nil {
 		NotFoundHandler(w, r)
 		return
 	}
 
-	var decklistData aggregation.Decklist
-	err = json.Unmarshal(fileContent, &decklistData)
+	var standingsData aggregation.SeasonStandings
+	err = json.Unmarshal(fileContent, &standingsData)
 	if err != nil {
-		http.Error(w, "Error parsing decklist data", http.StatusInternalServerError)
+		http.Error(w, "Error loading standings data", http.StatusInternalServerError)
 		return
 	}
 
 	templateData := map[string]interface{}{
-		"ActivePage": "",
+		"ActivePage": "leaderboards",
 		"Scheme":     templates.ColorScheme(),
-		"Decklist":   decklistData,
+		"Series":     s,
+		"Standings":  standingsData,
 	}
-
-	templates.RenderTemplate(w, "decklist.tmpl", templateData)
+	templates.RenderTemplate(w, r, "standings.tmpl", templateData)
 }
 
-func ImagesHandler(w http.ResponseWriter, r *http.Request) {
-	scryfallURL := r.URL.Query().Get("url")
-	if scryfallURL == "" {
-		http.Error(w, "Missing 'url' query parameter", http.StatusBadRequest)
+func DecklistHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
 		return
 	}
-	parsedURL, err := url.Parse(scryfallURL)
+
+	fileContent, err := readSeriesFile(s, "decklists", r.PathValue("id")+".json")
 	if err != nil {
-		http.Error(w, "Invalid Scryfall URL", http.StatusBadRequest)
+		NotFoundHandler(w, r)
 		return
 	}
 
-	parsedURL.RawQuery = ""
-	parsedURL.Fragment = ""
-	pathParts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
-	if len(pathParts) == 0 {
-		http.Error(w, "Invalid Scryfall URL", http.StatusBadRequest)
+	var decklistData aggregation.Decklist
+	err = json.Unmarshal(fileContent, &decklistData)
+	if err != nil {
+		http.Error(w, "Error parsing decklist data", http.StatusInternalServerError)
 		return
 	}
-	filename := pathParts[len(pathParts)-1]
 
-	// Ensure the directory exists
-	if _, err := os.Stat("static/images/scryfall"); os.IsNotExist(err) {
-		err = os.MkdirAll("static/images/scryfall", 0755)
-		if err != nil {
-			http.Error(w, "Failed to create image directory", http.StatusInternalServerError)
-			return
-		}
+	templateData := map[string]interface{}{
+		"ActivePage": "",
+		"Scheme":     templates.ColorScheme(),
+		"Series":     s,
+		"Decklist":   decklistData,
 	}
 
-	imagePath := "static/images/scryfall/" + filename
+	templates.RenderTemplate(w, r, "decklist.tmpl", templateData)
+}
 
-	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-		resp, err := http.Get(scryfallURL)
+// ImagesHandler serves a Scryfall card image from the cache, fetching it the first time it is asked for
+func ImagesHandler(cache *images.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		image, err := images.Parse(r.URL.Query().Get("url"))
 		if err != nil {
-			http.Error(w, "Failed to fetch image from Scryfall", http.StatusInternalServerError)
+			http.Error(w, "Invalid Scryfall URL", http.StatusBadRequest)
 			return
 		}
-		defer resp.Body.Close()
 
-		// Save the image to the local file system
-		out, err := os.Create(imagePath)
+		path, err := cache.Get(r.Context(), image)
 		if err != nil {
-			http.Error(w, "Failed to save image", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "Error fetching card image", "err", err)
+			http.Error(w, "Failed to fetch image from Scryfall", http.StatusBadGateway)
 			return
 		}
-		defer out.Close()
 
-		_, err = io.Copy(out, resp.Body)
-		if err != nil {
-			http.Error(w, "Failed to save image", http.StatusInternalServerError)
-			return
-		}
+		// The file name holds the card ID, so the image does not change
+		w.Header().Set("Cache-Control", "public, max-age=2592000")
+		http.ServeFile(w, r, path)
 	}
-
-	http.Redirect(w, r, "/"+imagePath, http.StatusFound)
 }
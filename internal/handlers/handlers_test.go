@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPredictionsHandler(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	r := httptest.NewRequest(http.MethodGet, "/predictions", nil)
+	w := httptest.NewRecorder()
+	PredictionsHandler(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d before the stats are built, got %d", http.StatusNotFound, w.Code)
+	}
+
+	predictions := `{"overall": {"matches": 12}, "rating_modes": [
+		{"mode": "match", "active": true, "matches": 12, "elo_brier_score": 0.2311},
+		{"mode": "game", "matches": 12, "elo_brier_score": 0.2242}
+	], "calibration": [
+		{"range": "70-80%", "matches": 4, "expected_win_rate": 74.5, "actual_win_rate": 50}
+	], "upsets": [
+		{"date": "2025-08-20", "winner": "Carl", "loser": "Alice", "result": "2-1", "win_probability": 0.21, "url": "/events/2025-08-20"}
+	]}`
+	if err := os.MkdirAll(filepath.Join("files", "lists"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("files", "lists", "predictions.json"), []byte(predictions), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w = httptest.NewRecorder()
+	PredictionsHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	for _, shown := range []string{"0.2311", "0.2242", "74.50%", "Carl", "21%"} {
+		if !strings.Contains(w.Body.String(), shown) {
+			t.Errorf("Expected the page to show %s", shown)
+		}
+	}
+}
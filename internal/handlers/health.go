@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// seriesHealth compares the newest event of a series in the input files with the newest aggregated one
+type seriesHealth struct {
+	Series           string `json:"series"`
+	LatestEvent      string `json:"latest_event"`
+	LatestInputEvent string `json:"latest_input_event"`
+	Stale            bool   `json:"stale"`
+}
+
+type health struct {
+	Status     string         `json:"status"`
+	StatsBuilt time.Time      `json:"stats_built,omitzero"`
+	StatsAge   string         `json:"stats_age,omitempty"`
+	Series     []seriesHealth `json:"series"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// latestEvent returns the date of the newest event file in dir
+func latestEvent(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	latest := ""
+	for _, entry := range entries {
+		date, isJSON := strings.CutSuffix(entry.Name(), ".json")
+		if isJSON && !entry.IsDir() && date > latest {
+			latest = date
+		}
+	}
+	return latest, nil
+}
+
+func checkHealth() (health, error) {
+	result := health{Status: "ok", Series: []seriesHealth{}}
+
+	allSeries, err := loadSeries()
+	if err != nil {
+		return result, err
+	}
+	for _, s := range allSeries {
+		if s.Main() {
+			info, err := os.Stat(seriesFile(s, "lists", "events.json"))
+			if err != nil {
+				return result, err
+			}
+			result.StatsBuilt = info.ModTime().UTC().Truncate(time.Second)
+			result.StatsAge = time.Since(info.ModTime()).Round(time.Second).String()
+		}
+
+		aggregated, err := latestEvent(seriesFile(s, "events"))
+		if err != nil {
+			return result, err
+		}
+		// A series without any events yet has no input events directory
+		input, err := latestEvent(filepath.Join(s.InputDir(), "events"))
+		if err != nil && !os.IsNotExist(err) {
+			return result, err
+		}
+		result.Series = append(result.Series, seriesHealth{
+			Series:           s.ID,
+			LatestEvent:      aggregated,
+			LatestInputEvent: input,
+			Stale:            input > aggregated,
+		})
+	}
+	return result, nil
+}
+
+// HealthHandler reports whether the stats are served, when they were built and whether any input events are
+// missing from them
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	result, err := checkHealth()
+	status := http.StatusOK
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Health check failed", "err", err)
+		result.Status = "error"
+		result.Error = err.Error()
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"premodernonsdagar/internal/store"
+)
+
+// The input files are mirrored to a remote store when set, see UseInputMirror
+var inputs *store.Mirror
+
+// UseInputMirror makes every form post upload the input files it changed to the remote store
+func UseInputMirror(mirror *store.Mirror) {
+	inputs = mirror
+}
+
+// pushInputs uploads the input files changed by a post, after the handler is done with them
+func pushInputs(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r)
+		if inputs == nil || r.Method != http.MethodPost {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), time.Minute)
+		defer cancel()
+
+		pushed, err := inputs.PushLocalChanges(ctx)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error uploading input files", "err", err)
+			return
+		}
+		if len(pushed) > 0 {
+			slog.InfoContext(r.Context(), "Uploaded changed input files", "files", len(pushed))
+		}
+	}
+}
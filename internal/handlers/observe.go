@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"premodernonsdagar/internal/metrics"
+)
+
+var (
+	requestCount = metrics.NewCounter("premodern_http_requests_total",
+		"HTTP requests served, by route and status code.", "route", "code")
+	requestDuration = metrics.NewHistogram("premodern_http_request_duration_seconds",
+		"Time taken to serve HTTP requests, by route.", metrics.DefaultBuckets, "route")
+)
+
+// statusRecorder remembers the status and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// observe logs every request and counts it in the metrics by the route pattern it matched, so the
+// player and event pages each add up to one route
+func observe(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		duration := time.Since(started)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		requestCount.Inc(route, strconv.Itoa(recorder.status))
+		requestDuration.Observe(duration.Seconds(), route)
+		slog.InfoContext(r.Context(), "Request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", route,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"duration", duration.Round(time.Microsecond).String(),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
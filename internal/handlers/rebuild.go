@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/config"
+	"premodernonsdagar/internal/series"
+)
+
+// Rebuilds are written next to each other, and the one being served is named in currentBuildFile
+var (
+	buildsDir        = filepath.Join(series.OutputRoot, "builds")
+	currentBuildFile = filepath.Join(buildsDir, "current")
+)
+
+var ErrRebuildRunning = errors.New("a rebuild is already running")
+
+// The directory the aggregated stats are served from, swapped by a rebuild
+var servedRoot atomic.Value
+
+func outputRoot() string {
+	if root, ok := servedRoot.Load().(string); ok {
+		return root
+	}
+	return series.OutputRoot
+}
+
+// BuildStatus describes the running or last finished rebuild
+type BuildStatus struct {
+	Running  bool      `json:"running"`
+	Started  time.Time `json:"started,omitzero"`
+	Finished time.Time `json:"finished,omitzero"`
+	Duration string    `json:"duration,omitempty"`
+	Output   string    `json:"output,omitempty"`
+	Warnings []string  `json:"warnings"`
+	Error    string    `json:"error,omitempty"`
+}
+
+var rebuilds struct {
+	sync.Mutex
+	status BuildStatus
+}
+
+// ServeCurrentBuild serves the stats from the last rebuild, if the files have not been aggregated since
+func ServeCurrentBuild() error {
+	name, err := os.ReadFile(currentBuildFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read current build: %w", err)
+	}
+
+	dir := filepath.Join(buildsDir, strings.TrimSpace(string(name)))
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("failed to find current build: %w", err)
+	}
+	servedRoot.Store(dir)
+	dataChanged()
+	slog.Info("Serving rebuilt stats", "dir", dir)
+	return nil
+}
+
+// DiscardBuilds removes the rebuilds, after the served files have been aggregated again
+func DiscardBuilds() error {
+	servedRoot.Store(series.OutputRoot)
+	dataChanged()
+	if err := os.RemoveAll(buildsDir); err != nil {
+		return fmt.Errorf("failed to remove old builds: %w", err)
+	}
+	return nil
+}
+
+func startRebuild() bool {
+	rebuilds.Lock()
+	defer rebuilds.Unlock()
+	if rebuilds.status.Running {
+		return false
+	}
+	rebuilds.status = BuildStatus{Running: true, Started: time.Now(), Warnings: []string{}}
+	return true
+}
+
+// Rebuild aggregates the stats into a new directory and, when they are valid, serves them instead of the current ones.
+// Requests read either the old or the new stats in full, the directories are never changed while being served.
+func Rebuild(cfg config.Config) error {
+	if !startRebuild() {
+		return ErrRebuildRunning
+	}
+	return rebuild(cfg)
+}
+
+func rebuild(cfg config.Config) error {
+	rebuilds.Lock()
+	started := rebuilds.status.Started
+	rebuilds.Unlock()
+
+	dir, warnings, err := buildStats(cfg, started)
+	if err == nil {
+		err = swapBuild(dir)
+	}
+
+	status := BuildStatus{
+		Started:  started,
+		Finished: time.Now(),
+		Duration: time.Since(started).Round(time.Millisecond).String(),
+		Warnings: warnings,
+	}
+	if err != nil {
+		status.Error = err.Error()
+		slog.Error("Error rebuilding stats", "err", err)
+	} else {
+		status.Output = dir
+		slog.Info("Rebuilt stats", "duration", status.Duration)
+	}
+
+	rebuilds.Lock()
+	rebuilds.status = status
+	rebuilds.Unlock()
+	return err
+}
+
+// buildStats aggregates the stats into a new build directory and the database, the directory is removed again if anything fails
+func buildStats(cfg config.Config, started time.Time) (string, []string, error) {
+	if err := os.MkdirAll(buildsDir, 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create builds directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(buildsDir, started.Format("20060102-150405-"))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create build directory: %w", err)
+	}
+
+	warnings, err := aggregation.AggregateStatsInto(cfg, dir)
+	if err == nil {
+		err = aggregation.ValidateOutput(dir)
+	}
+	// The database is only written once the build is valid, so a failed rebuild leaves it as it was
+	if err == nil && db != nil {
+		err = aggregation.StoreDatabase(db, dir)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", warnings, err
+	}
+	return dir, warnings, nil
+}
+
+// swapBuild serves the new build, remembers it for restarts and removes the builds that are no longer served.
+// The build served until now is kept, since requests that started before the swap may still read it.
+func swapBuild(dir string) error {
+	previous := outputRoot()
+
+	tmp := currentBuildFile + ".tmp"
+	if err := os.WriteFile(tmp, []byte(filepath.Base(dir)), 0644); err != nil {
+		return fmt.Errorf("failed to write current build: %w", err)
+	}
+	if err := os.Rename(tmp, currentBuildFile); err != nil {
+		return fmt.Errorf("failed to write current build: %w", err)
+	}
+	servedRoot.Store(dir)
+	dataChanged()
+
+	entries, err := os.ReadDir(buildsDir)
+	if err != nil {
+		slog.Error("Error listing old builds", "err", err)
+		return nil
+	}
+	for _, entry := range entries {
+		path := filepath.Join(buildsDir, entry.Name())
+		if !entry.IsDir() || path == dir || path == previous {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			slog.Error("Error removing old build", "path", path, "err", err)
+		}
+	}
+	return nil
+}
+
+func writeBuildStatus(w http.ResponseWriter, status int) {
+	rebuilds.Lock()
+	current := rebuilds.status
+	rebuilds.Unlock()
+	if current.Warnings == nil {
+		current.Warnings = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(current)
+}
+
+// AdminRebuildStatusHandler reports the running or last rebuild
+func AdminRebuildStatusHandler(w http.ResponseWriter, r *http.Request) {
+	writeBuildStatus(w, http.StatusOK)
+}
+
+// AdminRebuildPostHandler starts a rebuild in the background and redirects to its status
+func AdminRebuildPostHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !startRebuild() {
+			writeBuildStatus(w, http.StatusConflict)
+			return
+		}
+		go rebuild(cfg)
+
+		http.Redirect(w, r, "/admin/rebuild", http.StatusSeeOther)
+	}
+}
@@ -2,15 +2,31 @@
 package handlers
 
 import (
+	"log/slog"
 	"net/http"
+
+	"premodernonsdagar/internal/assets"
+	"premodernonsdagar/internal/auth"
+	"premodernonsdagar/internal/compress"
 	"premodernonsdagar/internal/config"
+	"premodernonsdagar/internal/images"
+	"premodernonsdagar/internal/metrics"
+	"premodernonsdagar/internal/requestid"
 )
 
-func SetupRoutes(cfg config.Config) *http.ServeMux {
+func SetupRoutes(cfg config.Config) http.Handler {
 	mux := http.NewServeMux()
 
-	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
-	mux.Handle("HEAD /static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+	// GET patterns also match HEAD requests
+	mux.Handle("GET /static/", http.StripPrefix("/static/", assets.Handler()))
+
+	// Pages built from the stats are answered with 304 while the stats are unchanged, except when developing
+	page := func(handler http.HandlerFunc) http.HandlerFunc {
+		if cfg.DevelopmentEnvironment {
+			return handler
+		}
+		return cached(handler)
+	}
 
 	// Add a specific handler for favicon.ico to prevent it from being routed to the index page
 	mux.HandleFunc("GET /favicon.ico", func(w http.ResponseWriter, r *http.Request) {
@@ -24,31 +40,110 @@ func SetupRoutes(cfg config.Config) *http.ServeMux {
 			return
 		}
 		// If somehow a request to "/" gets here, redirect to index handler
-		IndexHandler(w, r)
+		page(IndexHandler)(w, r)
 	})
-	mux.HandleFunc("GET /about", AboutHandler)
-	mux.HandleFunc("GET /events", EventsHandler)
-	mux.HandleFunc("GET /events/{id}", EventDetailHandler)
+	mux.HandleFunc("GET /about", page(AboutHandler))
+	if cardImages, err := images.New(cfg.ImageCacheDir, cfg.ImageCacheSize); err != nil {
+		slog.Warn("Card images disabled", "err", err)
+	} else {
+		mux.HandleFunc("GET /images", ImagesHandler(cardImages))
+	}
 
-	mux.HandleFunc("GET /players", PlayersHandler)
-	mux.HandleFunc("GET /players/{id}", PlayerDetailHandler)
-	mux.HandleFunc("GET /leaderboards", LeaderboardsHandler)
-	mux.HandleFunc("GET /leaderboards/{season}", LeaderboardsDetailHandler)
-	mux.HandleFunc("GET /decklists/{id}", DecklistHandler)
-	mux.HandleFunc("GET /images", ImagesHandler)
+	// The main series is served from the root, the other series under /series/{series}
+	for _, prefix := range []string{"", "/series/{series}"} {
+		mux.HandleFunc("GET "+prefix+"/events", page(EventsHandler))
+		mux.HandleFunc("GET "+prefix+"/events/{id}", page(EventDetailHandler))
 
-	if cfg.DevelopmentEnvironment {
-		mux.HandleFunc("GET /admin/events", AdminEventsListHandler)
-		mux.HandleFunc("GET /admin/events/new", EventEntryHandler)
-		mux.HandleFunc("POST /admin/events/new", EventEntryPostHandler)
-		mux.HandleFunc("GET /admin/events/edit/{date}", EventEditHandler)
-		mux.HandleFunc("POST /admin/events/edit/{date}", EventEditPostHandler)
+		mux.HandleFunc("GET "+prefix+"/players", page(PlayersHandler))
+		mux.HandleFunc("GET "+prefix+"/players/{id}", page(PlayerDetailHandler))
+		mux.HandleFunc("GET "+prefix+"/achievements", page(AchievementsHandler))
+		mux.HandleFunc("GET "+prefix+"/leaderboards", page(LeaderboardsHandler))
+		mux.HandleFunc("GET "+prefix+"/leaderboards/{season}", page(LeaderboardsDetailHandler))
+		mux.HandleFunc("GET "+prefix+"/predictions", page(PredictionsHandler))
+		mux.HandleFunc("GET "+prefix+"/seasons/{season}/standings", page(SeasonStandingsHandler))
+		mux.HandleFunc("GET "+prefix+"/decklists/{id}", page(DecklistHandler))
+		mux.HandleFunc("GET "+prefix+"/signup", SignupHandler)
+		mux.HandleFunc("POST "+prefix+"/signup", pushInputs(SignupPostHandler))
+		mux.HandleFunc("GET "+prefix+"/calendar.ics", page(CalendarHandler))
+		mux.HandleFunc("GET "+prefix+"/exports/{file}", page(ExportHandler))
 	}
+	mux.HandleFunc("GET /series/{series}", SeriesHandler)
 
-	mux.HandleFunc("GET /_/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("ok"))
-	})
+	if authenticator := adminAuthenticator(cfg); authenticator != nil {
+		admin := func(handler http.HandlerFunc) http.Handler {
+			return authenticator.Require(pushInputs(handler))
+		}
+
+		mux.HandleFunc("GET /admin/login", AdminLoginHandler(authenticator))
+		mux.HandleFunc("POST /admin/login", AdminLoginPostHandler(authenticator))
+		mux.Handle("POST /admin/logout", admin(AdminLogoutHandler(authenticator)))
+
+		for _, prefix := range []string{"/admin", "/admin/series/{series}"} {
+			mux.Handle("GET "+prefix+"/events", admin(AdminEventsListHandler))
+			mux.Handle("GET "+prefix+"/events/new", admin(EventEntryHandler))
+			mux.Handle("POST "+prefix+"/events/new", admin(EventEntryPostHandler))
+			mux.Handle("GET "+prefix+"/events/import", admin(AdminImportHandler))
+			mux.Handle("POST "+prefix+"/events/import", admin(AdminImportPreviewHandler))
+			mux.Handle("POST "+prefix+"/events/import/save", admin(AdminImportSavePostHandler))
+			mux.Handle("GET "+prefix+"/events/{date}/edit", admin(EventEditHandler))
+			mux.Handle("POST "+prefix+"/events/{date}/edit", admin(EventEditPostHandler))
+			mux.Handle("GET "+prefix+"/events/{date}/history", admin(EventHistoryHandler))
+			mux.Handle("POST "+prefix+"/events/{date}/history/{version}/restore", admin(EventRestorePostHandler))
+			mux.Handle("GET "+prefix+"/events/{date}/decklists", admin(AdminDecklistsHandler))
+			mux.Handle("GET "+prefix+"/events/{date}/decklists/{player}", admin(AdminDecklistHandler))
+			mux.Handle("POST "+prefix+"/events/{date}/decklists/{player}", admin(AdminDecklistPostHandler))
+			mux.Handle("POST "+prefix+"/events/{date}/decklists/{player}/preview", admin(AdminDecklistPreviewHandler))
+			mux.Handle("GET "+prefix+"/signups", admin(AdminSignupsHandler))
+			mux.Handle("POST "+prefix+"/signups/{date}/remove", admin(AdminSignupRemovePostHandler))
+			mux.Handle("POST "+prefix+"/signups/{date}/event", admin(AdminSignupsEventPostHandler))
+		}
+		mux.Handle("GET /admin/rebuild", admin(AdminRebuildStatusHandler))
+		mux.Handle("POST /admin/rebuild", admin(AdminRebuildPostHandler(cfg)))
+		mux.Handle("GET /admin/players", admin(AdminPlayersHandler))
+		mux.Handle("POST /admin/players/{id}/rename", admin(AdminPlayerRenamePostHandler))
+		mux.Handle("POST /admin/players/{id}/aliases", admin(AdminPlayerAliasPostHandler))
+		mux.Handle("POST /admin/players/{id}/aliases/remove", admin(AdminPlayerAliasRemovePostHandler))
+		mux.Handle("POST /admin/players/{id}/merge", admin(AdminPlayerMergePostHandler))
+	}
+
+	mux.HandleFunc("GET /_/health", HealthHandler)
+	// The metrics are for monitoring, not visitors, so outside of development they need the token
+	if cfg.MetricsToken != "" || cfg.DevelopmentEnvironment {
+		mux.Handle("GET /metrics", metrics.Handler(cfg.MetricsToken))
+	}
+
+	return requestid.Middleware(observe(compress.Middleware(mux)))
+}
+
+// adminAuthenticator returns nil when the admin section should not be served.
+// Without configured users the admin section is only available, without login, in the development environment.
+func adminAuthenticator(cfg config.Config) *auth.Authenticator {
+	if !cfg.DevelopmentEnvironment && !cfg.AdminEnabled {
+		return nil
+	}
+
+	users, err := auth.ParseUsers(cfg.AdminUsers)
+	if err != nil {
+		slog.Warn("Admin section disabled", "err", err)
+		return nil
+	}
+
+	proxies, err := auth.ParseProxies(cfg.TrustedProxies)
+	if err != nil {
+		slog.Warn("Admin section disabled", "err", err)
+		return nil
+	}
+
+	if len(users) > 0 {
+		authenticator := auth.NewAuthenticator(users, !cfg.DevelopmentEnvironment)
+		authenticator.TrustProxies(proxies)
+		return authenticator
+	}
+
+	if cfg.DevelopmentEnvironment {
+		return auth.NewOpenAuthenticator()
+	}
 
-	return mux
+	slog.Warn("Admin section disabled: ADMIN_ENABLED is set but ADMIN_USERS is empty")
+	return nil
 }
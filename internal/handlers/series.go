@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"premodernonsdagar/internal/database"
+	"premodernonsdagar/internal/series"
+)
+
+// The series config is read once, changes need a restart like the rest of the config
+var loadSeries = sync.OnceValues(series.Load)
+
+// requestSeries returns the series a page belongs to, responding with a 404 if it does not exist
+func requestSeries(w http.ResponseWriter, r *http.Request) (series.Series, bool) {
+	allSeries, err := loadSeries()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading series", "err", err)
+		http.Error(w, "Error loading series", http.StatusInternalServerError)
+		return series.Series{}, false
+	}
+
+	s, found := series.Find(allSeries, r.PathValue("series"))
+	if !found {
+		NotFoundHandler(w, r)
+		return series.Series{}, false
+	}
+	return s, true
+}
+
+// seriesFile returns the path of an aggregated file of the series, in the build being served
+func seriesFile(s series.Series, elem ...string) string {
+	return filepath.Join(append([]string{s.OutputDirIn(outputRoot())}, elem...)...)
+}
+
+// The database with the aggregated data, the files are read when it is not used
+var db *database.DB
+
+// UseDatabase makes the pages read the aggregated data from the database instead of the files
+func UseDatabase(database *database.DB) {
+	db = database
+}
+
+// readSeriesFile reads an aggregated file of the series, from the database when it is used.
+// The names often come from the URL, so anything but a plain file or directory name does not exist.
+func readSeriesFile(s series.Series, elem ...string) ([]byte, error) {
+	for _, name := range elem {
+		if !filepath.IsLocal(name) || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("invalid file name %q: %w", name, fs.ErrNotExist)
+		}
+	}
+	if db != nil {
+		return db.Document(s.ID, path.Join(elem...))
+	}
+	return os.ReadFile(seriesFile(s, elem...))
+}
+
+func SeriesHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return
+	}
+	http.Redirect(w, r, s.URLPrefix()+"/events", http.StatusFound)
+}
+
+// adminURL returns the URL of an admin page of the series
+func adminURL(s series.Series, path string) string {
+	return "/admin" + s.URLPrefix() + path
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/series"
+	"premodernonsdagar/internal/signup"
+	"premodernonsdagar/internal/templates"
+	"premodernonsdagar/internal/utils"
+)
+
+const (
+	maxSignupFieldLength = 100
+	maxSignupSize        = maxDecklistSize + 16<<10 // The decklist and the rest of the form
+)
+
+// nextSignupEvent returns the series and the event to sign up for, responding with a 404 if there is none
+func nextSignupEvent(w http.ResponseWriter, r *http.Request) (series.Series, series.Occurrence, bool) {
+	s, ok := requestSeries(w, r)
+	if !ok {
+		return series.Series{}, series.Occurrence{}, false
+	}
+
+	next, scheduled := s.NextEvent(time.Now())
+	if !scheduled {
+		NotFoundHandler(w, r)
+		return series.Series{}, series.Occurrence{}, false
+	}
+	return s, next, true
+}
+
+func renderSignupPage(w http.ResponseWriter, r *http.Request, status int, message string, form signup.Signup) {
+	s, next, ok := nextSignupEvent(w, r)
+	if !ok {
+		return
+	}
+	eventDate := next.Date.Format("2006-01-02")
+
+	signups, err := signup.List(s.InputDir(), eventDate)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading sign-ups", "err", err)
+		http.Error(w, "Error reading sign-ups", http.StatusInternalServerError)
+		return
+	}
+
+	playerNames, err := getAvailablePlayerNames()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading players", "err", err)
+	}
+
+	templateData := map[string]interface{}{
+		"ActivePage": "index",
+		"Scheme":     templates.ColorScheme(),
+		"Series":     s,
+		"EventDate":  eventDate,
+		"WeekNumber": utils.SwedishWeekNumber(next.Date),
+		"Event":      next,
+		"Signups":    signups,
+		"Players":    playerNames,
+		"SignedUp":   r.URL.Query().Get("signed_up"),
+		"Error":      message,
+		"Form":       form,
+	}
+	templates.RenderTemplateStatus(w, r, status, "signup.tmpl", templateData)
+}
+
+func SignupHandler(w http.ResponseWriter, r *http.Request) {
+	renderSignupPage(w, r, http.StatusOK, "", signup.Signup{})
+}
+
+func SignupPostHandler(w http.ResponseWriter, r *http.Request) {
+	s, next, ok := nextSignupEvent(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSignupSize)
+	err := r.ParseForm()
+	if err == nil {
+		err = r.ParseMultipartForm(maxSignupSize)
+	}
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		renderSignupPage(w, r, http.StatusRequestEntityTooLarge, "The sign-up is too large, paste a shorter decklist", signup.Signup{})
+		return
+	}
+
+	// Hidden from people, so only bots fill it in
+	if r.FormValue("website") != "" {
+		http.Redirect(w, r, s.URLPrefix()+"/signup", http.StatusSeeOther)
+		return
+	}
+
+	form := signup.Signup{
+		Player: strings.Join(strings.Fields(r.FormValue("player")), " "),
+		Deck:   strings.TrimSpace(r.FormValue("deck")),
+	}
+
+	decklist, err := readDecklistInput(r)
+	if err != nil {
+		renderSignupPage(w, r, http.StatusBadRequest, err.Error(), form)
+		return
+	}
+	form.Decklist = strings.TrimSpace(strings.ReplaceAll(decklist, "\r\n", "\n"))
+
+	if form.Player == "" {
+		renderSignupPage(w, r, http.StatusBadRequest, "Pick your name from the list, or write it if you are new", form)
+		return
+	}
+	if len(form.Player) > maxSignupFieldLength || len(form.Deck) > maxSignupFieldLength {
+		renderSignupPage(w, r, http.StatusBadRequest, "Names are limited to 100 characters", form)
+		return
+	}
+	if len(form.Decklist) > maxDecklistSize {
+		renderSignupPage(w, r, http.StatusBadRequest, "The decklist is too long", form)
+		return
+	}
+
+	// Resolve aliases, so a misspelled name signs up the right player
+	registry, err := aggregation.LoadPlayerRegistry()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading player registry", "err", err)
+		http.Error(w, "Error loading player registry", http.StatusInternalServerError)
+		return
+	}
+	form.Player = registry.Name(form.Player)
+
+	playerNames, err := getAvailablePlayerNames()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading players", "err", err)
+	}
+	form.NewPlayer = true
+	for _, name := range playerNames {
+		if strings.EqualFold(name, form.Player) {
+			form.Player = name
+			form.NewPlayer = false
+			break
+		}
+	}
+
+	err = signup.Add(s.InputDir(), next.Date.Format("2006-01-02"), form)
+	if errors.Is(err, signup.ErrAlreadySignedUp) {
+		renderSignupPage(w, r, http.StatusConflict, form.Player+" is already signed up, ask the organizer to change the sign-up", form)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error saving sign-up", "err", err)
+		http.Error(w, "Error saving sign-up", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, s.URLPrefix()+"/signup?signed_up=1", http.StatusSeeOther)
+}
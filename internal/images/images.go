@@ -0,0 +1,339 @@
+// Package images caches the Scryfall card images on disk, so they are fetched once and served from the site.
+package images
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"premodernonsdagar/internal/metrics"
+)
+
+// Only card images are fetched, from https://cards.scryfall.io/<kind>/<face>/<x>/<y>/<id>.<ext>
+const allowedHost = "cards.scryfall.io"
+
+var (
+	cardImagePath = regexp.MustCompile(`^/([a-z_]+)/(front|back)/[0-9a-f]/[0-9a-f]/([0-9a-f-]{36})\.(jpg|png)$`)
+	cardVersion   = regexp.MustCompile(`^[0-9]{1,20}$`)
+)
+
+const (
+	maxImageSize = 5 << 20
+	fetchTimeout = 20 * time.Second
+)
+
+var (
+	cacheRequests = metrics.NewCounter("premodern_image_cache_requests_total",
+		"Card images asked for, by whether they were cached, fetched or failed.", "result")
+	cacheSize = metrics.NewGauge("premodern_image_cache_bytes", "Bytes of card images in the cache.")
+)
+
+var (
+	ErrNotAllowed = errors.New("not a Scryfall card image")
+	ErrNotImage   = errors.New("response is not an image")
+)
+
+// CardImage is a validated card image URL and the name of its file in the cache
+type CardImage struct {
+	URL  string
+	File string
+}
+
+// Parse checks that rawURL is a Scryfall card image. The file name includes the kind and face, since the
+// different crops of a card share the same ID.
+func Parse(rawURL string) (CardImage, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host != allowedHost || u.User != nil {
+		return CardImage{}, ErrNotAllowed
+	}
+	match := cardImagePath.FindStringSubmatch(u.Path)
+	if match == nil {
+		return CardImage{}, ErrNotAllowed
+	}
+
+	image := CardImage{
+		URL:  "https://" + allowedHost + u.Path,
+		File: fmt.Sprintf("%s-%s-%s.%s", match[1], match[2], match[3], match[4]),
+	}
+	if cardVersion.MatchString(u.RawQuery) {
+		image.URL += "?" + u.RawQuery
+	}
+	return image, nil
+}
+
+type entry struct {
+	file string
+	size int64
+}
+
+type fetch struct {
+	done chan struct{}
+	err  error
+}
+
+// Cache keeps the card images in Dir, removing the least recently used when they take up more than MaxBytes
+type Cache struct {
+	Dir      string
+	MaxBytes int64
+	client   *http.Client
+
+	mu       sync.Mutex
+	order    *list.List // Front is the most recently used
+	entries  map[string]*list.Element
+	size     int64
+	fetching map[string]*fetch
+}
+
+// New creates a cache in dir, picking up the images already there oldest first
+func New(dir string, maxBytes int64) (*Cache, error) {
+	c := &Cache{
+		Dir:      dir,
+		MaxBytes: maxBytes,
+		client: &http.Client{
+			Timeout: fetchTimeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if req.URL.Host != allowedHost || len(via) > 3 {
+					return ErrNotAllowed
+				}
+				return nil
+			},
+		},
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		fetching: make(map[string]*fetch),
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image directory: %w", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image directory: %w", err)
+	}
+
+	type cached struct {
+		entry
+		used time.Time
+	}
+	found := []cached{}
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		// Left behind by a write that never finished
+		if strings.HasPrefix(file.Name(), ".tmp-") {
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+		found = append(found, cached{entry{file.Name(), info.Size()}, info.ModTime()})
+	}
+	// Added oldest first, so the most recently used end up in front
+	slices.SortFunc(found, func(a, b cached) int { return a.used.Compare(b.used) })
+	for _, f := range found {
+		c.add(f.entry)
+	}
+	c.evict("")
+	return c, nil
+}
+
+func (c *Cache) add(e entry) {
+	c.entries[e.file] = c.order.PushFront(&e)
+	c.size += e.size
+	cacheSize.Set(float64(c.size))
+}
+
+// evict removes the least recently used images until the cache fits, except keep
+func (c *Cache) evict(keep string) {
+	for c.size > c.MaxBytes && c.order.Len() > 0 {
+		oldest := c.order.Back()
+		e := oldest.Value.(*entry)
+		if e.file == keep {
+			if c.order.Len() == 1 {
+				return
+			}
+			c.order.MoveToFront(oldest)
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.Dir, e.file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Error("Error removing cached image", "file", e.file, "err", err)
+		}
+		c.order.Remove(oldest)
+		delete(c.entries, e.file)
+		c.size -= e.size
+		cacheSize.Set(float64(c.size))
+	}
+}
+
+// Get returns the path of the cached image, fetching it first if needed. Concurrent requests for the same
+// image wait for a single fetch.
+func (c *Cache) Get(ctx context.Context, image CardImage) (string, error) {
+	path := filepath.Join(c.Dir, image.File)
+
+	c.mu.Lock()
+	if element, cached := c.entries[image.File]; cached {
+		c.order.MoveToFront(element)
+		c.mu.Unlock()
+		cacheRequests.Inc("hit")
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return path, nil
+	}
+	f, running := c.fetching[image.File]
+	if !running {
+		f = &fetch{done: make(chan struct{})}
+		c.fetching[image.File] = f
+	}
+	c.mu.Unlock()
+
+	if !running {
+		// The fetch is not tied to the first request, the others waiting for it may outlive it
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		size, err := c.fetch(fetchCtx, image, path)
+		cancel()
+
+		c.mu.Lock()
+		f.err = err
+		if err == nil {
+			c.add(entry{image.File, size})
+			c.evict(image.File)
+		}
+		delete(c.fetching, image.File)
+		c.mu.Unlock()
+		close(f.done)
+	}
+
+	select {
+	case <-f.done:
+		if f.err != nil {
+			cacheRequests.Inc("error")
+			return "", f.err
+		}
+		cacheRequests.Inc("miss")
+		return path, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// fetch downloads the image and writes it to path in one step, so a half written image is never served
+func (c *Cache) fetch(ctx context.Context, image CardImage, path string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, image.URL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch %s: %w", image.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to fetch %s: %s", image.URL, resp.Status)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+		return 0, fmt.Errorf("failed to fetch %s: %w", image.URL, ErrNotImage)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", image.URL, err)
+	}
+	if len(data) > maxImageSize {
+		return 0, fmt.Errorf("failed to fetch %s: image larger than %d bytes", image.URL, maxImageSize)
+	}
+	if !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return 0, fmt.Errorf("failed to fetch %s: %w", image.URL, ErrNotImage)
+	}
+
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create image file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to write image file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write image file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("failed to write image file: %w", err)
+	}
+	return int64(len(data)), nil
+}
+
+// Prefetch caches the images of urls that are not cached yet with a few fetches at a time, and returns how many
+// it fetched. URLs that are not card images are skipped, and failed fetches are reported after trying the rest.
+func (c *Cache) Prefetch(ctx context.Context, urls []string, workers int) (int, error) {
+	queue := make(chan CardImage)
+	var (
+		mu       sync.Mutex
+		fetched  int
+		failed   int
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for image := range queue {
+				_, err := c.Get(ctx, image)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					failed++
+				} else {
+					fetched++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, rawURL := range urls {
+		image, err := Parse(rawURL)
+		if err != nil {
+			continue
+		}
+		c.mu.Lock()
+		_, cached := c.entries[image.File]
+		c.mu.Unlock()
+		if cached {
+			continue
+		}
+		select {
+		case queue <- image:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return fetched, err
+	}
+	if failed > 0 {
+		return fetched, fmt.Errorf("failed to fetch %d images: %w", failed, firstErr)
+	}
+	return fetched, nil
+}
@@ -0,0 +1,201 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const cardURL = "https://cards.scryfall.io/border_crop/front/9/4/942cf220-472c-48f6-8f60-993939ea5ab8.jpg?1562055436"
+
+func TestParse(t *testing.T) {
+	card, err := Parse(cardURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.File != "border_crop-front-942cf220-472c-48f6-8f60-993939ea5ab8.jpg" || card.URL != cardURL {
+		t.Errorf("Unexpected card image %+v", card)
+	}
+
+	for _, rawURL := range []string{
+		"http://cards.scryfall.io/border_crop/front/9/4/942cf220-472c-48f6-8f60-993939ea5ab8.jpg",
+		"https://169.254.169.254/latest/meta-data/",
+		"https://cards.scryfall.io.example.com/border_crop/front/9/4/942cf220-472c-48f6-8f60-993939ea5ab8.jpg",
+		"https://user@cards.scryfall.io/border_crop/front/9/4/942cf220-472c-48f6-8f60-993939ea5ab8.jpg",
+		"https://cards.scryfall.io/../../etc/passwd",
+		"https://cards.scryfall.io/border_crop/front/9/4/942cf220.jpg",
+	} {
+		if _, err := Parse(rawURL); !errors.Is(err, ErrNotAllowed) {
+			t.Errorf("Expected %s to not be allowed, got %v", rawURL, err)
+		}
+	}
+}
+
+func testImage(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.White)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// scryfall serves an image for every card path, and counts the requests
+type scryfall struct {
+	image    []byte
+	requests atomic.Int32
+	release  chan struct{}
+}
+
+func (s *scryfall) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	switch filepath.Base(r.URL.Path) {
+	case "00000000-0000-0000-0000-000000000000.jpg":
+		http.Error(w, "not found", http.StatusNotFound)
+	case "11111111-1111-1111-1111-111111111111.jpg":
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("<html>not an image</html>"))
+	default:
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(s.image)
+	}
+}
+
+// newTestCache sends the requests for cards.scryfall.io to server
+func newTestCache(t *testing.T, server *httptest.Server, maxBytes int64) *Cache {
+	c, err := New(t.TempDir(), maxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, _ := url.Parse(server.URL)
+	c.client.Transport = roundTripper(func(r *http.Request) (*http.Response, error) {
+		r.URL.Scheme, r.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	return c
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func card(id string) CardImage {
+	card, err := Parse("https://cards.scryfall.io/border_crop/front/0/0/" + id + ".jpg")
+	if err != nil {
+		panic(err)
+	}
+	return card
+}
+
+func TestGet(t *testing.T) {
+	upstream := &scryfall{image: testImage(t), release: make(chan struct{})}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+	c := newTestCache(t, server, 1<<20)
+
+	// Concurrent requests for the same image share one fetch
+	var wg sync.WaitGroup
+	paths := make([]string, 5)
+	for i := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			paths[i], _ = c.Get(context.Background(), card("942cf220-472c-48f6-8f60-993939ea5ab8"))
+		}()
+	}
+	close(upstream.release)
+	wg.Wait()
+	if upstream.requests.Load() != 1 {
+		t.Errorf("Expected a single fetch, got %d", upstream.requests.Load())
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil || !bytes.Equal(data, upstream.image) {
+		t.Errorf("Expected the image to be cached, got %v", err)
+	}
+
+	if _, err := c.Get(context.Background(), card("00000000-0000-0000-0000-000000000000")); err == nil {
+		t.Error("Expected a missing image to fail")
+	}
+	if _, err := c.Get(context.Background(), card("11111111-1111-1111-1111-111111111111")); !errors.Is(err, ErrNotImage) {
+		t.Errorf("Expected a page claiming to be an image to fail, got %v", err)
+	}
+	files, _ := os.ReadDir(c.Dir)
+	if len(files) != 1 {
+		t.Errorf("Expected only the image to be written, got %d files", len(files))
+	}
+}
+
+func TestEviction(t *testing.T) {
+	upstream := &scryfall{image: testImage(t)}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+	size := int64(len(upstream.image))
+	c := newTestCache(t, server, 2*size)
+
+	ids := []string{
+		"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+		"bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb",
+		"cccccccc-cccc-cccc-cccc-cccccccccccc",
+	}
+	ctx := context.Background()
+	c.Get(ctx, card(ids[0]))
+	c.Get(ctx, card(ids[1]))
+	c.Get(ctx, card(ids[0]))
+	c.Get(ctx, card(ids[2]))
+
+	exists := func(id string) bool {
+		_, err := os.Stat(filepath.Join(c.Dir, card(id).File))
+		return err == nil
+	}
+	if !exists(ids[0]) || exists(ids[1]) || !exists(ids[2]) {
+		t.Errorf("Expected the least recently used image to be removed")
+	}
+
+	// A restarted cache picks up the images on disk
+	restarted, err := New(c.Dir, 2*size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restarted.size != 2*size || restarted.order.Len() != 2 {
+		t.Errorf("Expected two cached images after a restart, got %d", restarted.order.Len())
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	upstream := &scryfall{image: testImage(t)}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+	c := newTestCache(t, server, 1<<20)
+
+	urls := []string{
+		card("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa").URL,
+		card("bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb").URL,
+		"https://example.com/not-a-card.jpg",
+	}
+	if fetched, err := c.Prefetch(context.Background(), urls, 2); fetched != 2 || err != nil {
+		t.Fatalf("Expected two fetched images, got %d and %v", fetched, err)
+	}
+	if fetched, _ := c.Prefetch(context.Background(), urls, 2); fetched != 0 {
+		t.Errorf("Expected the cached images to be skipped, fetched %d", fetched)
+	}
+	if upstream.requests.Load() != 2 {
+		t.Errorf("Expected two requests, got %d", upstream.requests.Load())
+	}
+}
@@ -0,0 +1,233 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The header names used for each column by the different exports
+var csvColumns = map[string][]string{
+	"round":   {"round", "rnd", "round number"},
+	"player1": {"player 1", "player1", "player 1 name", "player", "player name", "name"},
+	"player2": {"player 2", "player2", "player 2 name", "opponent", "opponent name"},
+	"result":  {"result", "results", "outcome", "match result"},
+	"wins1":   {"player 1 wins", "player 1 game wins", "wins", "game wins"},
+	"wins2":   {"player 2 wins", "player 2 game wins", "losses", "game losses"},
+	"deck1":   {"player 1 deck", "player 1 decklist", "deck", "decklist", "archetype"},
+	"deck2":   {"player 2 deck", "player 2 decklist", "opponent deck", "opponent decklist"},
+}
+
+var (
+	nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+	digits          = regexp.MustCompile(`\d+`)
+)
+
+func headerName(header string) string {
+	return strings.TrimSpace(nonAlphanumeric.ReplaceAllString(strings.ToLower(header), " "))
+}
+
+// csvDelimiter guesses the delimiter from the header, spreadsheets with a Swedish locale use semicolons
+func csvDelimiter(header string) rune {
+	switch {
+	case strings.Count(header, "\t") > strings.Count(header, ","):
+		return '\t'
+	case strings.Count(header, ";") > strings.Count(header, ","):
+		return ';'
+	default:
+		return ','
+	}
+}
+
+func parseCSV(data []byte, normalizeName func(string) string) (*Result, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	header, _, _ := strings.Cut(string(data), "\n")
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = csvDelimiter(header)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("the export is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, header := range rows[0] {
+		for column, names := range csvColumns {
+			for _, name := range names {
+				if _, found := columns[column]; !found && headerName(header) == name {
+					columns[column] = i
+				}
+			}
+		}
+	}
+	if _, found := columns["player1"]; !found {
+		return nil, fmt.Errorf("could not find a player column in the header %q", strings.Join(rows[0], ", "))
+	}
+
+	field := func(row []string, column string) string {
+		if i, found := columns[column]; found && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	result := newResult()
+	for _, row := range rows[1:] {
+		player1 := normalizeName(field(row, "player1"))
+		if player1 == "" {
+			continue
+		}
+		round, err := strconv.Atoi(digits.FindString(field(row, "round")))
+		if err == nil {
+			result.addRound(round)
+		}
+		result.addPlayer(player1, field(row, "deck1"))
+
+		opponent := field(row, "player2")
+		if isBye(opponent) {
+			continue
+		}
+		player2 := normalizeName(opponent)
+		result.addPlayer(player2, field(row, "deck2"))
+
+		score, ok := parseResult(field(row, "result"), player2, opponent)
+		if _, hasWins := columns["wins1"]; !ok && hasWins {
+			score, ok = parseResult(field(row, "wins1") + "-" + field(row, "wins2"))
+		}
+		if !ok {
+			result.Skipped = append(result.Skipped, strings.Join(row, ", "))
+			continue
+		}
+		result.addMatch(round, player1, player2, score)
+	}
+	return result, nil
+}
+
+type meleeMatch struct {
+	RoundNumber int
+	Competitors []struct {
+		GameWinCount int
+		Team         struct {
+			Players []struct {
+				DisplayName string
+			}
+		}
+		Decklists []struct {
+			DecklistName string
+		}
+	}
+}
+
+func parseMeleeJSON(data []byte) (*Result, error) {
+	var matches []meleeMatch
+	if err := json.Unmarshal(data, &matches); err != nil {
+		// The API wraps the matches in an object
+		var wrapped struct {
+			Data []meleeMatch
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("failed to parse Melee JSON: %w", err)
+		}
+		matches = wrapped.Data
+	}
+
+	result := newResult()
+	for _, match := range matches {
+		result.addRound(match.RoundNumber)
+
+		names := []string{}
+		wins := []int{}
+		for _, competitor := range match.Competitors {
+			players := []string{}
+			for _, player := range competitor.Team.Players {
+				players = append(players, cleanName(player.DisplayName))
+			}
+			deck := ""
+			if len(competitor.Decklists) > 0 {
+				deck = competitor.Decklists[0].DecklistName
+			}
+			name := strings.Join(players, " & ")
+			result.addPlayer(name, deck)
+			names = append(names, name)
+			wins = append(wins, competitor.GameWinCount)
+		}
+
+		if len(names) == 2 && names[0] != "" && names[1] != "" {
+			result.addMatch(match.RoundNumber, names[0], names[1], fmt.Sprintf("%d-%d", wins[0], wins[1]))
+		}
+	}
+	return result, nil
+}
+
+var (
+	roundLine     = regexp.MustCompile(`(?i)^round\s+(\d+)`)
+	tablePrefix   = regexp.MustCompile(`(?i)^(?:table\s+)?\d+[.):]?\s+`)
+	byeLine       = regexp.MustCompile(`(?i)^(.+?)(?:\s+vs\.?)?[\s:,-]+(?:\*\s*)?bye(?:\s*\*)?(?:[\s:,]+\d.*)?$`)
+	versusLine    = regexp.MustCompile(`(?i)^(.+?)\s+vs\.?\s+(.+?)[\s:,]+(\d+\s*-\s*\d+(?:\s*-\s*\d+)?)$`)
+	scoreLine     = regexp.MustCompile(`^(.+?)\s+(\d+\s*-\s*\d+(?:\s*-\s*\d+)?)\s+(\p{L}.*)$`)
+	standingsLine = regexp.MustCompile(`^(.+?)\s+(?:\d+\s+)?(\d+)-(\d+)(?:-(\d+))?(?:\s+[\d.,%\s]*)?$`)
+	recordNumbers = regexp.MustCompile(`\d+`)
+)
+
+// parseText reads pairings with results, like "Anna Svensson 2-1 Erik Berg", or standings, like "1. Anna Svensson 9 3-0-0"
+func parseText(data []byte) (*Result, error) {
+	result := newResult()
+	currentRound := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if round := roundLine.FindStringSubmatch(line); round != nil {
+			n, _ := strconv.Atoi(round[1])
+			result.addRound(n)
+			currentRound = n
+			continue
+		}
+
+		line = tablePrefix.ReplaceAllString(line, "")
+		if bye := byeLine.FindStringSubmatch(line); bye != nil {
+			result.addPlayer(cleanName(bye[1]), "")
+			continue
+		}
+
+		var player1, player2, score string
+		if m := versusLine.FindStringSubmatch(line); m != nil {
+			player1, player2, score = m[1], m[2], m[3]
+		} else if m := scoreLine.FindStringSubmatch(line); m != nil {
+			player1, score, player2 = m[1], m[2], m[3]
+		}
+		if player1 != "" {
+			player1, player2 = cleanName(player1), cleanName(player2)
+			result.addPlayer(player1, "")
+			result.addPlayer(player2, "")
+			score, _ = parseResult(score)
+			result.addMatch(currentRound, player1, player2, score)
+			continue
+		}
+
+		if m := standingsLine.FindStringSubmatch(line); m != nil {
+			result.addPlayer(cleanName(m[1]), "")
+			rounds := 0
+			for _, n := range recordNumbers.FindAllString(strings.Join(m[2:], " "), -1) {
+				games, _ := strconv.Atoi(n)
+				rounds += games
+			}
+			result.addRound(rounds)
+			continue
+		}
+
+		result.Skipped = append(result.Skipped, line)
+	}
+	return result, nil
+}
@@ -0,0 +1,192 @@
+// Package importer converts the exports of tournament software into events.
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"premodernonsdagar/internal/aggregation"
+)
+
+const (
+	FormatEventLink = "eventlink"
+	FormatMelee     = "melee"
+	FormatText      = "text"
+)
+
+// Formats lists the supported exports with a description for the admin
+var Formats = []struct {
+	ID          string
+	Description string
+}{
+	{FormatEventLink, "EventLink or Wizards Event Reporter CSV"},
+	{FormatMelee, "Melee.gg CSV or JSON"},
+	{FormatText, "MTGO style pairings or standings text"},
+}
+
+var (
+	gamesPattern = regexp.MustCompile(`(\d+)\s*-\s*(\d+)(?:\s*-\s*\d+)?`)
+	wonPattern   = regexp.MustCompile(`(?i)\b(won|wins|win)\b`)
+	lostPattern  = regexp.MustCompile(`(?i)^\s*(lost|loss|lose)\b`)
+)
+
+// Result is an event read from an export, with the player names as written in the export
+type Result struct {
+	Rounds  int
+	Players []string // In the order they first appear
+	Decks   map[string]string
+	Matches []aggregation.Match
+	Skipped []string // Rows that could not be read, shown in the preview
+
+	pairs map[string]bool // The round and the players of the matches added, in sorted order
+}
+
+func newResult() *Result {
+	return &Result{Decks: make(map[string]string), Matches: []aggregation.Match{}, Skipped: []string{}, pairs: make(map[string]bool)}
+}
+
+func (r *Result) addPlayer(name, deck string) {
+	if name == "" {
+		return
+	}
+	if _, exists := r.Decks[name]; !exists {
+		r.Players = append(r.Players, name)
+		r.Decks[name] = ""
+	}
+	if deck != "" {
+		r.Decks[name] = deck
+	}
+}
+
+func (r *Result) addRound(round int) {
+	r.Rounds = max(r.Rounds, round)
+}
+
+// addMatch adds a match once per round, exports that list every match from both sides repeat it within the round.
+// The round is 0 for exports that do not list the rounds.
+func (r *Result) addMatch(round int, player1, player2, result string) {
+	pair := []string{player1, player2}
+	sort.Strings(pair)
+	if key := strconv.Itoa(round) + "\x00" + strings.Join(pair, "\x00"); !r.pairs[key] {
+		r.pairs[key] = true
+		r.Matches = append(r.Matches, aggregation.Match{Player1: player1, Player2: player2, Result: result, Round: round})
+	}
+}
+
+// finish fills in the number of rounds when the export does not list them
+func (r *Result) finish() {
+	if r.Rounds > 0 {
+		return
+	}
+	played := make(map[string]int)
+	for _, m := range r.Matches {
+		played[m.Player1]++
+		played[m.Player2]++
+		r.Rounds = max(r.Rounds, played[m.Player1], played[m.Player2])
+	}
+}
+
+// Parse reads an export in one of the supported formats
+func Parse(format string, data []byte) (*Result, error) {
+	var (
+		result *Result
+		err    error
+	)
+	switch format {
+	case FormatEventLink:
+		result, err = parseCSV(data, flipName)
+	case FormatMelee:
+		if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			result, err = parseMeleeJSON(data)
+		} else {
+			result, err = parseCSV(data, cleanName)
+		}
+	case FormatText:
+		result, err = parseText(data)
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Players) == 0 {
+		return nil, fmt.Errorf("no players found in the export")
+	}
+	result.finish()
+	return result, nil
+}
+
+func cleanName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// flipName turns "Last, First" into "First Last"
+func flipName(name string) string {
+	if last, first, found := strings.Cut(name, ","); found && strings.TrimSpace(first) != "" {
+		return cleanName(first + " " + last)
+	}
+	return cleanName(name)
+}
+
+func isBye(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return name == "" || name == "bye" || name == "* bye *" || name == "-"
+}
+
+// parseResult turns a result written in any of the usual ways into games won by player 1 and player 2.
+// A result naming the winner, like "Anna won 2-1-0", is turned around when player 2, written any of the ways given, is the winner.
+func parseResult(text string, player2 ...string) (string, bool) {
+	games := gamesPattern.FindStringSubmatch(text)
+	if games == nil {
+		return "", false
+	}
+	wins1, _ := strconv.Atoi(games[1])
+	wins2, _ := strconv.Atoi(games[2])
+
+	won := wonPattern.FindStringIndex(text)
+	player2Won := false
+	for _, name := range player2 {
+		if won != nil && name != "" && strings.Contains(strings.ToLower(text[:won[0]]), strings.ToLower(name)) {
+			player2Won = true
+		}
+	}
+
+	switch {
+	case player2Won:
+		wins1, wins2 = min(wins1, wins2), max(wins1, wins2)
+	case won != nil:
+		wins1, wins2 = max(wins1, wins2), min(wins1, wins2)
+	case lostPattern.MatchString(text):
+		wins1, wins2 = min(wins1, wins2), max(wins1, wins2)
+	}
+	return fmt.Sprintf("%d-%d", wins1, wins2), true
+}
+
+// Event builds the event, naming the players as chosen in the preview
+func (r *Result) Event(name, date string, players map[string]string) aggregation.InputEvent {
+	rename := func(exported string) string {
+		if player := strings.TrimSpace(players[exported]); player != "" {
+			return player
+		}
+		return exported
+	}
+
+	event := aggregation.InputEvent{
+		Name:       name,
+		Date:       date,
+		Rounds:     r.Rounds,
+		PlayerInfo: make(map[string]aggregation.PlayerEventInfo),
+		Matches:    []aggregation.Match{},
+	}
+	for _, player := range r.Players {
+		event.PlayerInfo[rename(player)] = aggregation.PlayerEventInfo{Deck: r.Decks[player]}
+	}
+	for _, m := range r.Matches {
+		event.Matches = append(event.Matches, aggregation.Match{Player1: rename(m.Player1), Player2: rename(m.Player2), Result: m.Result, Round: m.Round})
+	}
+	return event
+}
@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+
+	"premodernonsdagar/internal/aggregation"
+)
+
+func matchStrings(matches []aggregation.Match) []string {
+	result := []string{}
+	for _, m := range matches {
+		result = append(result, m.Player1+" "+m.Result+" "+m.Player2)
+	}
+	return result
+}
+
+func TestParseEventLinkCSV(t *testing.T) {
+	export := "\xef\xbb\xbfRound;Table;Player;Opponent;Result\n" +
+		"1;1;Svensson, Anna;Berg, Erik;Svensson, Anna won 2-1-0\n" +
+		"1;1;Berg, Erik;Svensson, Anna;Svensson, Anna won 2-1-0\n" +
+		"1;2;Lind, Sara;Holm, Per;Holm, Per won 2-0-0\n" +
+		"1;3;Ek, Maja;BYE;\n" +
+		"2;1;Svensson, Anna;Lind, Sara;Draw 1-1-1\n" +
+		"2;2;Berg, Erik;Ek, Maja;Not reported\n" +
+		"3;1;Berg, Erik;Svensson, Anna;Berg, Erik won 2-0-0\n" +
+		"3;1;Svensson, Anna;Berg, Erik;Berg, Erik won 2-0-0\n"
+
+	result, err := Parse(FormatEventLink, []byte(export))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// The rematch in the final round is kept, the same match listed from the other side is not
+	expected := []string{"Anna Svensson 2-1 Erik Berg", "Sara Lind 0-2 Per Holm", "Anna Svensson 1-1 Sara Lind", "Erik Berg 2-0 Anna Svensson"}
+	if got := matchStrings(result.Matches); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected matches %v, got %v", expected, got)
+	}
+	if len(result.Players) != 5 || result.Players[4] != "Maja Ek" {
+		t.Errorf("Expected 5 players including the bye, got %v", result.Players)
+	}
+	if result.Rounds != 3 {
+		t.Errorf("Expected 3 rounds, got %d", result.Rounds)
+	}
+	if len(result.Skipped) != 1 {
+		t.Errorf("Expected the unreported match to be skipped, got %v", result.Skipped)
+	}
+}
+
+func TestParseMeleeCSV(t *testing.T) {
+	export := "Table,Player 1,Player 1 Decklist,Player 2,Player 2 Decklist,Player 1 Wins,Player 2 Wins\n" +
+		"1,Anna Svensson,Goblins,Erik Berg,Stiflenought,0,2\n"
+
+	result, err := Parse(FormatMelee, []byte(export))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := matchStrings(result.Matches); !reflect.DeepEqual(got, []string{"Anna Svensson 0-2 Erik Berg"}) {
+		t.Errorf("Unexpected matches %v", got)
+	}
+	if result.Decks["Erik Berg"] != "Stiflenought" {
+		t.Errorf("Expected the decks to be read, got %v", result.Decks)
+	}
+	if result.Rounds != 1 {
+		t.Errorf("Expected the rounds to be counted from the matches, got %d", result.Rounds)
+	}
+}
+
+func TestParseMeleeJSON(t *testing.T) {
+	export := `{"data": [
+		{"RoundNumber": 3, "Competitors": [
+			{"GameWinCount": 1, "Team": {"Players": [{"DisplayName": "Anna Svensson"}]}, "Decklists": [{"DecklistName": "Goblins"}]},
+			{"GameWinCount": 2, "Team": {"Players": [{"DisplayName": "Erik  Berg"}]}}
+		]},
+		{"RoundNumber": 3, "Competitors": [
+			{"GameWinCount": 2, "Team": {"Players": [{"DisplayName": "Maja Ek"}]}}
+		]}
+	]}`
+
+	result, err := Parse(FormatMelee, []byte(export))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := matchStrings(result.Matches); !reflect.DeepEqual(got, []string{"Anna Svensson 1-2 Erik Berg"}) {
+		t.Errorf("Unexpected matches %v", got)
+	}
+	if len(result.Players) != 3 || result.Rounds != 3 || result.Decks["Anna Svensson"] != "Goblins" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	export := `Round 1
+1. Anna Svensson 2-1 Erik Berg
+Table 2: Sara Lind vs Per Holm 0-2
+Maja Ek - BYE
+
+Round 2
+Anna Svensson vs. Sara Lind: 1-1-1
+something unreadable`
+
+	result, err := Parse(FormatText, []byte(export))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	expected := []string{"Anna Svensson 2-1 Erik Berg", "Sara Lind 0-2 Per Holm", "Anna Svensson 1-1 Sara Lind"}
+	if got := matchStrings(result.Matches); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected matches %v, got %v", expected, got)
+	}
+	if result.Rounds != 2 || len(result.Players) != 5 {
+		t.Errorf("Expected 2 rounds and 5 players, got %d and %v", result.Rounds, result.Players)
+	}
+	if !reflect.DeepEqual(result.Skipped, []string{"something unreadable"}) {
+		t.Errorf("Unexpected skipped lines %v", result.Skipped)
+	}
+}
+
+func TestParseStandingsText(t *testing.T) {
+	export := `1. Anna Svensson 9 3-0-0 55.56%
+2 Erik Berg 6 2-1-0
+3) Sara Lind 1-2`
+
+	result, err := Parse(FormatText, []byte(export))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Players, []string{"Anna Svensson", "Erik Berg", "Sara Lind"}) {
+		t.Errorf("Unexpected players %v", result.Players)
+	}
+	if len(result.Matches) != 0 || result.Rounds != 3 {
+		t.Errorf("Expected no matches and 3 rounds, got %d and %d", len(result.Matches), result.Rounds)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse("swiss", []byte("Anna 2-0 Erik")); err == nil {
+		t.Error("Expected an unknown format to fail")
+	}
+	if _, err := Parse(FormatEventLink, []byte("Table,Deck\n1,Goblins\n")); err == nil {
+		t.Error("Expected a CSV without a player column to fail")
+	}
+	if _, err := Parse(FormatText, []byte("\n\n")); err == nil {
+		t.Error("Expected an export without players to fail")
+	}
+}
+
+func TestMatchNames(t *testing.T) {
+	registry := aggregation.NewPlayerRegistry(nil)
+	player, err := registry.Register("Erik Berg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.AddAlias(player.ID, "Erik B"); err != nil {
+		t.Fatal(err)
+	}
+
+	known := []string{"Anna Svensson", "Erik Berg", "Sara Lind"}
+	matches := MatchNames([]string{"anna  svensson", "Erik B", "Sara Lindh", "Lind Sara", "Maja Ek"}, known, registry)
+
+	expected := []NameMatch{
+		{Export: "anna  svensson", Player: "Anna Svensson", How: MatchExact},
+		{Export: "Erik B", Player: "Erik Berg", How: MatchAlias},
+		{Export: "Sara Lindh", Player: "Sara Lind", How: MatchSimilar},
+		{Export: "Lind Sara", Player: "Sara Lind", How: MatchSimilar},
+		{Export: "Maja Ek", Player: "Maja Ek", How: MatchNew},
+	}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, matches)
+	}
+}
+
+func TestEventRenamesPlayers(t *testing.T) {
+	result, err := Parse(FormatText, []byte("Anna S 2-0 Erik Berg"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	event := result.Event("Invitational", "2025-12-13", map[string]string{"Anna S": "Anna Svensson"})
+	if event.Matches[0].Player1 != "Anna Svensson" || event.Matches[0].Player2 != "Erik Berg" {
+		t.Errorf("Expected the players to be renamed, got %+v", event.Matches[0])
+	}
+	if _, exists := event.PlayerInfo["Anna Svensson"]; !exists || len(event.PlayerInfo) != 2 {
+		t.Errorf("Expected player info for the renamed players, got %v", event.PlayerInfo)
+	}
+	if report := aggregation.ValidateEvent(event); report.HasErrors() {
+		t.Errorf("Expected the event to be valid, got %v", report.Errors())
+	}
+}
+
+func TestImportedRematchIsValid(t *testing.T) {
+	export := "Round 1\nAnna Svensson 2-1 Erik Berg\nErik Berg 1-2 Anna Svensson\nRound 2\nErik Berg 2-0 Anna Svensson\n"
+	result, err := Parse(FormatText, []byte(export))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := []string{"Anna Svensson 2-1 Erik Berg", "Erik Berg 2-0 Anna Svensson"}
+	if got := matchStrings(result.Matches); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected matches %v, got %v", expected, got)
+	}
+	event := result.Event("Invitational", "2025-12-13", nil)
+	if event.Matches[0].Round != 1 || event.Matches[1].Round != 2 {
+		t.Errorf("Expected the rounds to be kept, got %+v", event.Matches)
+	}
+	if report := aggregation.ValidateEvent(event); report.HasErrors() {
+		t.Errorf("Expected the rematch to be valid, got %v", report.Errors())
+	}
+}
@@ -0,0 +1,69 @@
+package importer
+
+import (
+	"strings"
+
+	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/pkg/levenshtein"
+)
+
+const (
+	MatchExact   = "exact"
+	MatchAlias   = "alias"
+	MatchSimilar = "similar"
+	MatchNew     = "new"
+)
+
+// NameMatch is the player suggested for a name in an export
+type NameMatch struct {
+	Export string
+	Player string // The name to use in the event, the exported name for new players
+	How    string
+}
+
+func normalizedName(name string) string {
+	return strings.ToLower(cleanName(name))
+}
+
+// similarEnough allows a couple of typos, but fewer in short names
+func similarEnough(distance int, name string) bool {
+	return distance <= 2 && distance*4 <= len(name)
+}
+
+// MatchNames suggests a known player for every exported name, through the registry's aliases or by
+// spelling, with the first and last name either way around
+func MatchNames(names, known []string, registry *aggregation.PlayerRegistry) []NameMatch {
+	byNormalized := make(map[string]string, len(known))
+	for _, name := range known {
+		byNormalized[normalizedName(name)] = name
+	}
+
+	matches := make([]NameMatch, 0, len(names))
+	for _, name := range names {
+		match := NameMatch{Export: name, Player: name, How: MatchNew}
+
+		if player, exists := byNormalized[normalizedName(name)]; exists {
+			match.Player, match.How = player, MatchExact
+		} else if player, exists := byNormalized[normalizedName(registry.Name(name))]; exists {
+			match.Player, match.How = player, MatchAlias
+		} else {
+			candidates := []string{normalizedName(name)}
+			if fields := strings.Fields(candidates[0]); len(fields) == 2 {
+				candidates = append(candidates, fields[1]+" "+fields[0])
+			}
+
+			best := -1
+			for normalized, player := range byNormalized {
+				for _, candidate := range candidates {
+					distance := levenshtein.Distance(candidate, normalized)
+					if similarEnough(distance, normalized) && (best < 0 || distance < best || (distance == best && player < match.Player)) {
+						best = distance
+						match.Player, match.How = player, MatchSimilar
+					}
+				}
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
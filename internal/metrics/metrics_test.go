@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	requests := NewCounter("test_requests_total", "Requests served.", "route", "code")
+	requests.Inc("GET /events", "200")
+	requests.Inc("GET /events", "200")
+	requests.Inc(`GET /players/{id}`, "404")
+
+	size := NewGauge("test_cache_bytes", "Bytes in the cache.")
+	size.Set(2048)
+
+	duration := NewHistogram("test_duration_seconds", "Time taken.", []float64{0.1, 1}, "route")
+	duration.Observe(0.05, "GET /events")
+	duration.Observe(0.5, "GET /events")
+	duration.Observe(5, "GET /events")
+
+	var buf bytes.Buffer
+	Write(&buf)
+	expected := `# HELP test_requests_total Requests served.
+# TYPE test_requests_total counter
+test_requests_total{route="GET /events",code="200"} 2
+test_requests_total{route="GET /players/{id}",code="404"} 1
+# HELP test_cache_bytes Bytes in the cache.
+# TYPE test_cache_bytes gauge
+test_cache_bytes 2048
+# HELP test_duration_seconds Time taken.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="GET /events",le="0.1"} 1
+test_duration_seconds_bucket{route="GET /events",le="1"} 2
+test_duration_seconds_bucket{route="GET /events",le="+Inf"} 3
+test_duration_seconds_sum{route="GET /events"} 5.55
+test_duration_seconds_count{route="GET /events"} 3
+`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	if requests.Value("GET /events", "200") != 2 {
+		t.Errorf("Expected a count of 2, got %v", requests.Value("GET /events", "200"))
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	if got := labelString([]string{"path"}, []string{"a\"b\\c\nd"}); got != `{path="a\"b\\c\nd"}` {
+		t.Errorf("Unexpected labels %s", got)
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	handler := Handler("s3cret")
+	tests := map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"s3cret":        http.StatusUnauthorized,
+		"Bearer s3cret": http.StatusOK,
+	}
+	for authorization, expected := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != expected {
+			t.Errorf("Expected %d for %q, got %d", expected, authorization, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	Handler("").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the metrics without a token to be open, got %d", w.Code)
+	}
+}
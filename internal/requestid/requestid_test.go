@@ -0,0 +1,55 @@
+package requestid
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromRequest(r)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(seen) != 16 || w.Header().Get(Header) != seen {
+		t.Errorf("Expected a new ID in the context and the response, got %q and %q", seen, w.Header().Get(Header))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(Header, "proxy-1234")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if seen != "proxy-1234" {
+		t.Errorf("Expected the ID from the proxy to be kept, got %q", seen)
+	}
+
+	r.Header.Set(Header, "<script>")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if seen == "<script>" {
+		t.Error("Expected an invalid ID to be replaced")
+	}
+
+	if id := FromRequest(httptest.NewRequest(http.MethodGet, "/", nil)); id != "-" {
+		t.Errorf("Expected no ID outside the middleware, got %q", id)
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(LogHandler{slog.NewTextHandler(&buf, nil)})
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "Rendering page")
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(Header, "abc123")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if !strings.Contains(buf.String(), "request_id=abc123") {
+		t.Errorf("Expected the request ID in the log, got %s", buf.String())
+	}
+}
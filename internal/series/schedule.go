@@ -0,0 +1,229 @@
+package series
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Schedule describes when a series meets, and the exceptions to its regular rule
+type Schedule struct {
+	Weekday   string `json:"weekday,omitempty"` // Empty for series with only special events
+	Weeks     string `json:"weeks,omitempty"`   // "even" or "odd" week numbers, empty for every week
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+	Location  string `json:"location,omitempty"`
+
+	Cancelled []Cancellation `json:"cancelled,omitempty"`
+	Moved     []Move         `json:"moved,omitempty"`
+	Breaks    []Break        `json:"breaks,omitempty"`
+	Special   []SpecialEvent `json:"special,omitempty"`
+}
+
+type Cancellation struct {
+	Date   string `json:"date"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type Move struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Break cancels every regular event from one date up to and including another
+type Break struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// SpecialEvent is held outside of the regular schedule, the times and location default to the regular ones
+type SpecialEvent struct {
+	Date      string `json:"date"`
+	Name      string `json:"name"`
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+	Location  string `json:"location,omitempty"`
+}
+
+// Occurrence is a single date on the schedule
+type Occurrence struct {
+	Date      time.Time
+	Name      string // Only set for special events
+	StartTime string
+	EndTime   string
+	Location  string
+	MovedFrom string // The regular date of a moved event
+	Cancelled bool
+	Note      string // Why the event was cancelled or moved
+}
+
+func validDate(date string) bool {
+	_, err := time.Parse(dateLayout, date)
+	return err == nil
+}
+
+func validTime(clock string) bool {
+	_, err := time.Parse("15:04", clock)
+	return clock == "" || err == nil
+}
+
+func (sc *Schedule) validate() error {
+	if _, exists := weekdays[strings.ToLower(sc.Weekday)]; sc.Weekday != "" && !exists {
+		return fmt.Errorf("scheduled on unknown weekday %q", sc.Weekday)
+	}
+	if sc.Weeks != "" && sc.Weeks != "even" && sc.Weeks != "odd" {
+		return fmt.Errorf("weeks %q, expected even, odd or nothing", sc.Weeks)
+	}
+	if !validTime(sc.StartTime) || !validTime(sc.EndTime) {
+		return fmt.Errorf("times must be written as 17:00")
+	}
+
+	for _, c := range sc.Cancelled {
+		if !validDate(c.Date) {
+			return fmt.Errorf("cancelled date %q is not a date", c.Date)
+		}
+	}
+	for _, m := range sc.Moved {
+		from, err := time.Parse(dateLayout, m.From)
+		if err != nil || !sc.regular(from) {
+			return fmt.Errorf("moved date %q is not a regular event date", m.From)
+		}
+		if !validDate(m.To) {
+			return fmt.Errorf("date %q that %s is moved to is not a date", m.To, m.From)
+		}
+	}
+	for _, b := range sc.Breaks {
+		if !validDate(b.From) || !validDate(b.To) || b.To < b.From {
+			return fmt.Errorf("break from %q to %q is not a date range", b.From, b.To)
+		}
+	}
+	for _, special := range sc.Special {
+		if !validDate(special.Date) {
+			return fmt.Errorf("special event date %q is not a date", special.Date)
+		}
+		if strings.TrimSpace(special.Name) == "" {
+			return fmt.Errorf("special event on %s needs a name", special.Date)
+		}
+		if !validTime(special.StartTime) || !validTime(special.EndTime) {
+			return fmt.Errorf("times of the special event on %s must be written as 17:00", special.Date)
+		}
+	}
+	return nil
+}
+
+// regular reports whether the regular rule puts an event on the day
+func (sc *Schedule) regular(day time.Time) bool {
+	weekday, exists := weekdays[strings.ToLower(sc.Weekday)]
+	if !exists || day.Weekday() != weekday {
+		return false
+	}
+	_, week := day.ISOWeek()
+	return sc.Weeks == "" || (sc.Weeks == "even") == (week%2 == 0)
+}
+
+// cancelled returns the reason a regular event is not held on the date
+func (sc *Schedule) cancelled(date string) (string, bool) {
+	for _, c := range sc.Cancelled {
+		if c.Date == date {
+			return c.Reason, true
+		}
+	}
+	for _, b := range sc.Breaks {
+		if b.From <= date && date <= b.To {
+			return b.Reason, true
+		}
+	}
+	return "", false
+}
+
+func (sc *Schedule) moved(date string) bool {
+	for _, m := range sc.Moved {
+		if m.From == date {
+			return true
+		}
+	}
+	return false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Occurrences lists the events scheduled between two dates, including both, together with the
+// cancelled regular events. A moved event is listed on the date it was moved to.
+func (s Series) Occurrences(from, to time.Time) []Occurrence {
+	if s.Schedule == nil {
+		return []Occurrence{}
+	}
+	sc := s.Schedule
+	first, last := startOfDay(from), startOfDay(to)
+	inRange := func(date string) (time.Time, bool) {
+		day, err := time.ParseInLocation(dateLayout, date, first.Location())
+		return day, err == nil && !day.Before(first) && !day.After(last)
+	}
+	regular := func(day time.Time) Occurrence {
+		return Occurrence{Date: day, StartTime: sc.StartTime, EndTime: sc.EndTime, Location: sc.Location}
+	}
+
+	occurrences := []Occurrence{}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		if !sc.regular(day) || sc.moved(date) {
+			continue
+		}
+		occurrence := regular(day)
+		occurrence.Note, occurrence.Cancelled = sc.cancelled(date)
+		occurrences = append(occurrences, occurrence)
+	}
+
+	for _, m := range sc.Moved {
+		if day, ok := inRange(m.To); ok {
+			occurrence := regular(day)
+			occurrence.MovedFrom, occurrence.Note = m.From, m.Reason
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+
+	for _, special := range sc.Special {
+		if day, ok := inRange(special.Date); ok {
+			occurrences = append(occurrences, Occurrence{
+				Date:      day,
+				Name:      special.Name,
+				StartTime: cmp.Or(special.StartTime, sc.StartTime),
+				EndTime:   cmp.Or(special.EndTime, sc.EndTime),
+				Location:  cmp.Or(special.Location, sc.Location),
+			})
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].Date.Before(occurrences[j].Date)
+	})
+	return occurrences
+}
+
+// NextEvent returns the next event that is not cancelled, on or after the date and within a year
+func (s Series) NextEvent(date time.Time) (Occurrence, bool) {
+	for _, occurrence := range s.Occurrences(date, date.AddDate(1, 0, 0)) {
+		if !occurrence.Cancelled {
+			return occurrence, true
+		}
+	}
+	return Occurrence{}, false
+}
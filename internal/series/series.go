@@ -0,0 +1,169 @@
+// Package series describes the event series tracked by the site, each with its own schedule, rules and stats.
+package series
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const configPath = "input/series.json"
+
+var validID = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+type Series struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Format       string    `json:"format"`
+	Rounds       int       `json:"rounds,omitempty"`        // Default number of rounds for new events
+	CardDatabase string    `json:"card_database,omitempty"` // Card pool used to match decklists
+	SeasonMonths int       `json:"season_months,omitempty"` // Length of a season, must divide the year evenly
+	Schedule     *Schedule `json:"schedule,omitempty"`      // Empty for series without regular events
+
+	LeaderboardMinEvents int `json:"leaderboard_min_events,omitempty"` // Events needed to be ranked on the win percentage leaderboards
+
+	main bool
+}
+
+// Default is the biweekly Wednesday series, used when there is no series config
+func Default() Series {
+	return Series{
+		ID:           "onsdagar",
+		Name:         "Premodern Onsdagar",
+		Format:       "Premodern",
+		Rounds:       4,
+		CardDatabase: "files/db.json",
+		SeasonMonths: 6,
+		Schedule: &Schedule{
+			Weekday:   "wednesday",
+			Weeks:     "even",
+			StartTime: "17:00",
+			Location:  "Biljardpalatset, Fridhemsplan, Stockholm",
+		},
+		LeaderboardMinEvents: 3,
+		main:                 true,
+	}
+}
+
+// Load reads the series config, the first series listed is the main series of the site
+func Load() ([]Series, error) {
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Series{Default()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read series config: %w", err)
+	}
+
+	var all []Series
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to parse series config: %w", err)
+	}
+	if err := validate(all); err != nil {
+		return nil, err
+	}
+
+	for i := range all {
+		all[i].main = i == 0
+		if all[i].Rounds == 0 {
+			all[i].Rounds = 4
+		}
+		if all[i].CardDatabase == "" {
+			all[i].CardDatabase = "files/db.json"
+		}
+		if all[i].SeasonMonths == 0 {
+			all[i].SeasonMonths = 6
+		}
+		if all[i].LeaderboardMinEvents == 0 {
+			all[i].LeaderboardMinEvents = 3
+		}
+	}
+	return all, nil
+}
+
+func validate(all []Series) error {
+	if len(all) == 0 {
+		return fmt.Errorf("series config must list at least one series")
+	}
+
+	seen := make(map[string]bool)
+	for _, s := range all {
+		if !validID.MatchString(s.ID) {
+			return fmt.Errorf("series ID %q may only contain lowercase letters, digits and dashes", s.ID)
+		}
+		if seen[s.ID] {
+			return fmt.Errorf("series ID %q is used twice", s.ID)
+		}
+		seen[s.ID] = true
+
+		if strings.TrimSpace(s.Name) == "" {
+			return fmt.Errorf("series %s needs a name", s.ID)
+		}
+		if s.Rounds < 0 {
+			return fmt.Errorf("series %s has a negative number of rounds", s.ID)
+		}
+		if s.SeasonMonths < 0 || (s.SeasonMonths > 0 && 12%s.SeasonMonths != 0) {
+			return fmt.Errorf("series %s has seasons of %d months, which does not divide a year", s.ID, s.SeasonMonths)
+		}
+		if s.LeaderboardMinEvents < 0 {
+			return fmt.Errorf("series %s has a negative number of events for the leaderboards", s.ID)
+		}
+		if s.Schedule != nil {
+			if err := s.Schedule.validate(); err != nil {
+				return fmt.Errorf("series %s: %w", s.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Find returns the series with the ID, the main series for an empty ID
+func Find(all []Series, id string) (Series, bool) {
+	for _, s := range all {
+		if s.ID == id || (id == "" && s.main) {
+			return s, true
+		}
+	}
+	return Series{}, false
+}
+
+// Main reports whether the series is served from the root of the site
+func (s Series) Main() bool {
+	return s.main
+}
+
+// InputDir holds the event files, decklists and sign-ups of the series
+func (s Series) InputDir() string {
+	if s.main {
+		return "input"
+	}
+	return filepath.Join("input", "series", s.ID)
+}
+
+// OutputRoot holds the aggregated stats of every series, unless they are rebuilt somewhere else
+const OutputRoot = "files"
+
+// OutputDir holds the aggregated stats of the series
+func (s Series) OutputDir() string {
+	return s.OutputDirIn(OutputRoot)
+}
+
+// OutputDirIn holds the aggregated stats of the series when they are built to root
+func (s Series) OutputDirIn(root string) string {
+	if s.main {
+		return root
+	}
+	return filepath.Join(root, "series", s.ID)
+}
+
+// URLPrefix is prepended to the public pages of the series
+func (s Series) URLPrefix() string {
+	if s.main {
+		return ""
+	}
+	return "/series/" + s.ID
+}
@@ -0,0 +1,197 @@
+package series
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"premodernonsdagar/internal/utils"
+)
+
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadWithoutConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	all, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(all) != 1 || all[0].ID != "onsdagar" || !all[0].Main() {
+		t.Fatalf("Expected only the default series, got %+v", all)
+	}
+	if all[0].InputDir() != "input" || all[0].OutputDir() != "files" || all[0].URLPrefix() != "" {
+		t.Errorf("Expected the default series to use the root directories, got %s, %s and %q", all[0].InputDir(), all[0].OutputDir(), all[0].URLPrefix())
+	}
+}
+
+func TestLoadFillsDefaults(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeConfig(t, `[
+		{"id": "onsdagar", "name": "Premodern Onsdagar", "format": "Premodern", "schedule": {"weekday": "Wednesday", "weeks": "even"}},
+		{"id": "oldschool", "name": "Old School", "format": "Old School", "rounds": 3, "card_database": "files/oldschool.json", "season_months": 12, "leaderboard_min_events": 2}
+	]`)
+
+	all, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !all[0].Main() || all[1].Main() {
+		t.Error("Expected only the first series to be the main series")
+	}
+	if all[0].Rounds != 4 || all[0].CardDatabase != "files/db.json" || all[0].SeasonMonths != 6 || all[0].LeaderboardMinEvents != 3 {
+		t.Errorf("Expected defaults to be filled in, got %+v", all[0])
+	}
+
+	oldschool, found := Find(all, "oldschool")
+	if !found {
+		t.Fatal("Expected to find the oldschool series")
+	}
+	if oldschool.Rounds != 3 || oldschool.SeasonMonths != 12 || oldschool.LeaderboardMinEvents != 2 {
+		t.Errorf("Expected the configured rules to be kept, got %+v", oldschool)
+	}
+	if oldschool.InputDir() != filepath.Join("input", "series", "oldschool") || oldschool.OutputDir() != filepath.Join("files", "series", "oldschool") {
+		t.Errorf("Unexpected directories %s and %s", oldschool.InputDir(), oldschool.OutputDir())
+	}
+	if oldschool.URLPrefix() != "/series/oldschool" {
+		t.Errorf("Expected prefix /series/oldschool, got %q", oldschool.URLPrefix())
+	}
+
+	if main, _ := Find(all, ""); main.ID != "onsdagar" {
+		t.Errorf("Expected an empty ID to find the main series, got %q", main.ID)
+	}
+	if _, found := Find(all, "legacy"); found {
+		t.Error("Expected an unknown series not to be found")
+	}
+}
+
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	tests := map[string]string{
+		"empty":        `[]`,
+		"bad id":       `[{"id": "Old School", "name": "Old School"}]`,
+		"duplicate id": `[{"id": "a", "name": "A"}, {"id": "a", "name": "B"}]`,
+		"no name":      `[{"id": "a"}]`,
+		"season":       `[{"id": "a", "name": "A", "season_months": 5}]`,
+		"leaderboards": `[{"id": "a", "name": "A", "leaderboard_min_events": -1}]`,
+		"weekday":      `[{"id": "a", "name": "A", "schedule": {"weekday": "onsdag"}}]`,
+		"weeks":        `[{"id": "a", "name": "A", "schedule": {"weekday": "monday", "weeks": "third"}}]`,
+		"start time":   `[{"id": "a", "name": "A", "schedule": {"weekday": "monday", "start_time": "5pm"}}]`,
+		"cancelled":    `[{"id": "a", "name": "A", "schedule": {"weekday": "monday", "cancelled": [{"date": "2025-13-01"}]}}]`,
+		"moved":        `[{"id": "a", "name": "A", "schedule": {"weekday": "monday", "moved": [{"from": "2025-10-01", "to": "2025-10-02"}]}}]`,
+		"break":        `[{"id": "a", "name": "A", "schedule": {"weekday": "monday", "breaks": [{"from": "2025-12-31", "to": "2025-12-01"}]}}]`,
+		"special":      `[{"id": "a", "name": "A", "schedule": {"weekday": "monday", "special": [{"date": "2025-10-04"}]}}]`,
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			writeConfig(t, config)
+
+			if _, err := Load(); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestNextEvent(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// The default series keeps the schedule the site has always used, except after
+	// a year with 53 weeks where the old calculation landed on an odd week
+	for date := start; date.Year() < 2027; date = date.AddDate(0, 0, 1) {
+		if _, week := utils.NextEvent(date).ISOWeek(); week%2 != 0 {
+			continue
+		}
+		next, scheduled := Default().NextEvent(date)
+		if expected := utils.NextEvent(date).Format("2006-01-02"); !scheduled || next.Date.Format("2006-01-02") != expected {
+			t.Fatalf("Expected %s to be followed by %s, got %s", date.Format("2006-01-02"), expected, next.Date.Format("2006-01-02"))
+		}
+	}
+
+	odd := Series{Schedule: &Schedule{Weekday: "wednesday", Weeks: "odd"}}
+	weekly := Series{Schedule: &Schedule{Weekday: "saturday"}}
+	for date := start; date.Year() < 2027; date = date.AddDate(0, 0, 1) {
+		day := startOfDay(date)
+
+		next, _ := odd.NextEvent(date)
+		if _, week := next.Date.ISOWeek(); next.Date.Weekday() != time.Wednesday || week%2 == 0 || next.Date.Before(day) || next.Date.Sub(day) >= 14*24*time.Hour {
+			t.Fatalf("Unexpected odd week event %s after %s", next.Date.Format("2006-01-02"), date.Format("2006-01-02"))
+		}
+
+		next, _ = weekly.NextEvent(date)
+		if next.Date.Weekday() != time.Saturday || next.Date.Before(day) || next.Date.Sub(day) >= 7*24*time.Hour {
+			t.Fatalf("Unexpected weekly event %s after %s", next.Date.Format("2006-01-02"), date.Format("2006-01-02"))
+		}
+	}
+
+	if _, scheduled := (Series{}).NextEvent(start); scheduled {
+		t.Error("Expected a series without a schedule to have no next event")
+	}
+}
+
+func TestOccurrencesWithExceptions(t *testing.T) {
+	s := Series{Schedule: &Schedule{
+		Weekday:   "wednesday",
+		Weeks:     "even",
+		StartTime: "17:00",
+		Cancelled: []Cancellation{{Date: "2025-10-15", Reason: "Venue closed"}},
+		Moved:     []Move{{From: "2025-10-29", To: "2025-10-30", Reason: "Halloween"}},
+		Breaks:    []Break{{From: "2025-12-20", To: "2026-01-06", Reason: "Christmas"}},
+		Special:   []SpecialEvent{{Date: "2025-11-01", Name: "Season Invitational", StartTime: "12:00"}},
+	}}
+	if err := s.Schedule.validate(); err != nil {
+		t.Fatalf("Expected the schedule to be valid, got %v", err)
+	}
+
+	from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)
+	got := []string{}
+	for _, o := range s.Occurrences(from, to) {
+		entry := o.Date.Format("2006-01-02") + " " + o.StartTime
+		if o.Name != "" {
+			entry += " " + o.Name
+		}
+		if o.MovedFrom != "" {
+			entry += " moved from " + o.MovedFrom
+		}
+		if o.Cancelled {
+			entry += " cancelled: " + o.Note
+		}
+		got = append(got, entry)
+	}
+
+	expected := []string{
+		"2025-10-01 17:00",
+		"2025-10-15 17:00 cancelled: Venue closed",
+		"2025-10-30 17:00 moved from 2025-10-29",
+		"2025-11-01 12:00 Season Invitational",
+		"2025-11-12 17:00",
+		"2025-11-26 17:00",
+		"2025-12-10 17:00",
+		"2025-12-24 17:00 cancelled: Christmas",
+		"2026-01-07 17:00",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected occurrences\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	next, _ := s.NextEvent(time.Date(2025, 10, 14, 18, 0, 0, 0, time.UTC))
+	if next.Date.Format("2006-01-02") != "2025-10-30" {
+		t.Errorf("Expected the next event to skip the cancelled and moved dates, got %s", next.Date.Format("2006-01-02"))
+	}
+	next, _ = s.NextEvent(time.Date(2025, 12, 11, 0, 0, 0, 0, time.UTC))
+	if next.Date.Format("2006-01-02") != "2026-01-07" {
+		t.Errorf("Expected the next event to come after the break, got %s", next.Date.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,143 @@
+// Package signup stores the players who have signed up for an upcoming event.
+// Every function takes the input directory of the series the event belongs to.
+package signup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const signupsDir = "signups"
+
+var (
+	ErrSignupNotFound  = errors.New("sign-up not found")
+	ErrAlreadySignedUp = errors.New("already signed up")
+)
+
+var mu sync.Mutex
+
+type Signup struct {
+	Player    string    `json:"player"`
+	NewPlayer bool      `json:"new_player,omitempty"` // The name was not in the players list when signing up
+	Deck      string    `json:"deck,omitempty"`
+	Decklist  string    `json:"decklist,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+type Pairing struct {
+	Player1 string
+	Player2 string // Empty for a bye
+}
+
+func signupsPath(dir, date string) string {
+	return filepath.Join(dir, signupsDir, date+".json")
+}
+
+func samePlayer(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+func load(dir, date string) ([]Signup, error) {
+	content, err := os.ReadFile(signupsPath(dir, date))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Signup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sign-ups for %s: %w", date, err)
+	}
+
+	var signups []Signup
+	if err := json.Unmarshal(content, &signups); err != nil {
+		return nil, fmt.Errorf("failed to parse sign-ups for %s: %w", date, err)
+	}
+	return signups, nil
+}
+
+func save(dir, date string, signups []Signup) error {
+	content, err := json.MarshalIndent(signups, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sign-ups: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, signupsDir), 0755); err != nil {
+		return fmt.Errorf("failed to create sign-ups directory: %w", err)
+	}
+	if err := os.WriteFile(signupsPath(dir, date), content, 0644); err != nil {
+		return fmt.Errorf("failed to write sign-ups for %s: %w", date, err)
+	}
+	return nil
+}
+
+// List returns the sign-ups for an event in the order they were made
+func List(dir, date string) ([]Signup, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return load(dir, date)
+}
+
+// Add signs a player up. Anyone can sign up, so a player who is already signed up is never replaced, the organizer
+// removes the earlier sign-up instead.
+func Add(dir, date string, s Signup) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	signups, err := load(dir, date)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range signups {
+		if samePlayer(existing.Player, s.Player) {
+			return ErrAlreadySignedUp
+		}
+	}
+
+	s.Time = time.Now().UTC()
+	return save(dir, date, append(signups, s))
+}
+
+func Remove(dir, date, player string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	signups, err := load(dir, date)
+	if err != nil {
+		return err
+	}
+
+	for i, existing := range signups {
+		if samePlayer(existing.Player, player) {
+			return save(dir, date, append(signups[:i], signups[i+1:]...))
+		}
+	}
+	return ErrSignupNotFound
+}
+
+// RandomPairings pairs the players at random for the first round, the player left over gets a bye
+func RandomPairings(signups []Signup) []Pairing {
+	players := make([]string, len(signups))
+	for i, s := range signups {
+		players[i] = s.Player
+	}
+	rand.Shuffle(len(players), func(i, j int) {
+		players[i], players[j] = players[j], players[i]
+	})
+
+	pairings := []Pairing{}
+	for i := 0; i < len(players); i += 2 {
+		pairing := Pairing{Player1: players[i]}
+		if i+1 < len(players) {
+			pairing.Player2 = players[i+1]
+		}
+		pairings = append(pairings, pairing)
+	}
+	return pairings
+}
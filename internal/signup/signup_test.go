@@ -0,0 +1,91 @@
+package signup
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddRejectsDuplicateSignup(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := Add("input", "2025-10-01", Signup{Player: "Anna"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := Add("input", "2025-10-01", Signup{Player: "Erik", Deck: "Goblins"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := Add("input", "2025-10-01", Signup{Player: "anna ", Deck: "Stiflenought"}); !errors.Is(err, ErrAlreadySignedUp) {
+		t.Fatalf("Expected ErrAlreadySignedUp, got %v", err)
+	}
+
+	signups, err := List("input", "2025-10-01")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(signups) != 2 {
+		t.Fatalf("Expected 2 sign-ups, got %d", len(signups))
+	}
+	if signups[0].Player != "Anna" || signups[0].Deck != "" {
+		t.Errorf("Expected the first sign-up to be kept, got %+v", signups[0])
+	}
+	if signups[0].Time.IsZero() {
+		t.Error("Expected the sign-up time to be set")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	Add("input", "2025-10-01", Signup{Player: "Anna"})
+	Add("input", "2025-10-01", Signup{Player: "Erik"})
+
+	if err := Remove("input", "2025-10-01", "ANNA"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := Remove("input", "2025-10-01", "Anna"); !errors.Is(err, ErrSignupNotFound) {
+		t.Errorf("Expected ErrSignupNotFound, got %v", err)
+	}
+
+	signups, _ := List("input", "2025-10-01")
+	if len(signups) != 1 || signups[0].Player != "Erik" {
+		t.Errorf("Expected only Erik to be left, got %+v", signups)
+	}
+}
+
+func TestListMissingFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	signups, err := List("input", "2025-10-01")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(signups) != 0 {
+		t.Errorf("Expected no sign-ups, got %d", len(signups))
+	}
+}
+
+func TestRandomPairings(t *testing.T) {
+	signups := []Signup{{Player: "A"}, {Player: "B"}, {Player: "C"}, {Player: "D"}, {Player: "E"}}
+
+	pairings := RandomPairings(signups)
+	if len(pairings) != 3 {
+		t.Fatalf("Expected 3 pairings, got %d", len(pairings))
+	}
+
+	seen := make(map[string]bool)
+	byes := 0
+	for _, pairing := range pairings {
+		seen[pairing.Player1] = true
+		if pairing.Player2 == "" {
+			byes++
+			continue
+		}
+		seen[pairing.Player2] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected every player to be paired once, got %v", seen)
+	}
+	if byes != 1 {
+		t.Errorf("Expected 1 bye, got %d", byes)
+	}
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Mirror keeps a local copy of the files in a remote store, which everything else reads and writes.
+// It remembers the ETag of every file when both copies were last the same, so it can tell which side changed it.
+type Mirror struct {
+	Local  Store
+	Remote Store
+
+	mu     sync.Mutex
+	synced map[string]string
+}
+
+func NewMirror(local, remote Store) *Mirror {
+	return &Mirror{Local: local, Remote: remote, synced: make(map[string]string)}
+}
+
+// LoadState restores the ETags saved by SaveState, so a new mirror can tell which side changed a file since the last
+// sync. A missing state file is the same as never having synced.
+func (m *Mirror) LoadState(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := json.Unmarshal(data, &m.synced); err != nil {
+		return fmt.Errorf("failed to parse sync state %s: %w", path, err)
+	}
+	return nil
+}
+
+// SaveState writes the ETag every file had when both copies were last the same
+func (m *Mirror) SaveState(path string) error {
+	m.mu.Lock()
+	data, err := json.MarshalIndent(m.synced, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// Pull copies the files changed or deleted in the remote store to the local store and returns their keys.
+// Files changed on both sides take the remote version, files only changed locally are left for Push.
+func (m *Mirror) Pull(ctx context.Context) ([]string, error) {
+	return m.copyChanged(ctx, m.Remote, m.Local)
+}
+
+// Push uploads the files changed or deleted locally to the remote store and returns their keys
+func (m *Mirror) Push(ctx context.Context) ([]string, error) {
+	return m.copyChanged(ctx, m.Local, m.Remote)
+}
+
+// PushLocalChanges uploads the files changed or deleted locally since the last sync without listing the remote store,
+// which is cheap enough to do after every save. Unlike Push it overwrites remote changes to the same files.
+func (m *Mirror) PushLocalChanges(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	local, err := m.Local.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	pushed := []string{}
+	exists := make(map[string]bool, len(local))
+	for _, object := range local {
+		exists[object.Key] = true
+		if m.synced[object.Key] == object.ETag {
+			continue
+		}
+
+		data, err := m.Local.Get(ctx, object.Key)
+		if err != nil {
+			return pushed, err
+		}
+		if err := m.Remote.Put(ctx, object.Key, data); err != nil {
+			return pushed, err
+		}
+		m.synced[object.Key] = ETag(data)
+		pushed = append(pushed, object.Key)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(m.synced)) {
+		if exists[key] {
+			continue
+		}
+		if err := m.Remote.Delete(ctx, key); err != nil {
+			return pushed, err
+		}
+		delete(m.synced, key)
+		pushed = append(pushed, key)
+	}
+
+	return pushed, nil
+}
+
+func (m *Mirror) copyChanged(ctx context.Context, from, to Store) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, err := from.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	target, err := to.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	targetETags := make(map[string]string, len(target))
+	for _, object := range target {
+		targetETags[object.Key] = object.ETag
+	}
+
+	copied := []string{}
+	for _, object := range source {
+		switch {
+		case targetETags[object.Key] == object.ETag:
+			m.synced[object.Key] = object.ETag
+			continue
+		case m.synced[object.Key] == object.ETag:
+			// Unchanged here since the last sync, so the other side has the newer version
+			continue
+		}
+
+		data, err := from.Get(ctx, object.Key)
+		if err != nil {
+			return copied, err
+		}
+		if err := to.Put(ctx, object.Key, data); err != nil {
+			return copied, err
+		}
+		m.synced[object.Key] = ETag(data)
+		copied = append(copied, object.Key)
+	}
+
+	// Files deleted on this side since the last sync are deleted on the other side too, unless it changed them
+	inSource := make(map[string]bool, len(source))
+	for _, object := range source {
+		inSource[object.Key] = true
+	}
+	for _, key := range slices.Sorted(maps.Keys(m.synced)) {
+		targetETag, inTarget := targetETags[key]
+		switch {
+		case inSource[key]:
+			continue
+		case !inTarget:
+			delete(m.synced, key)
+		case targetETag == m.synced[key]:
+			if err := to.Delete(ctx, key); err != nil {
+				return copied, err
+			}
+			delete(m.synced, key)
+			copied = append(copied, key)
+		}
+	}
+
+	return copied, nil
+}
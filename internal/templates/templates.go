@@ -2,25 +2,176 @@
 package templates
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
-	"log"
+	"io"
+	"io/fs"
+	"log/slog"
 	"net/http"
-	"path/filepath"
+	"os"
+	"sync"
+
+	"premodernonsdagar"
+	"premodernonsdagar/internal/requestid"
+)
+
+// The templates are read from the binary, or from disk when developing, see UseDisk
+var (
+	templateFiles fs.FS = mustSub(premodernonsdagar.Templates, "templates")
+	fromDisk      bool
+	cache         = sync.OnceValues(parseAll)
 )
 
-func RenderTemplate(w http.ResponseWriter, tmpl string, data interface{}) {
-	funcMap := template.FuncMap(TemplateFuncs)
-	t, err := template.New("base.tmpl").Funcs(funcMap).ParseFiles(
-		filepath.Join("templates", "base.tmpl"),
-		filepath.Join("templates", tmpl),
-	)
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// UseDisk reads the templates from dir on every render, so changes show without a restart
+func UseDisk(dir string) {
+	templateFiles = os.DirFS(dir)
+	fromDisk = true
+}
+
+// Load parses every template once, so the renders only execute them
+func Load() error {
+	_, err := cache()
+	return err
+}
+
+// TemplateError is returned when a template can not be found, parsed or executed
+type TemplateError struct {
+	Template string
+	Op       string
+	Err      error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("failed to %s template %s: %v", e.Op, e.Template, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+func parse(tmpl string) (*template.Template, error) {
+	t, err := template.New("base.tmpl").Funcs(template.FuncMap(TemplateFuncs)).ParseFS(templateFiles, "base.tmpl", tmpl)
+	if err != nil {
+		return nil, &TemplateError{Template: tmpl, Op: "parse", Err: err}
+	}
+	return t, nil
+}
+
+func pageTemplates() ([]string, error) {
+	names, err := fs.Glob(templateFiles, "*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	pages := []string{}
+	for _, name := range names {
+		if name != "base.tmpl" {
+			pages = append(pages, name)
+		}
+	}
+	return pages, nil
+}
+
+func parseAll() (map[string]*template.Template, error) {
+	pages, err := pageTemplates()
 	if err != nil {
-		log.Printf("Error parsing templates: %v", err)
+		return nil, err
+	}
+	parsed := map[string]*template.Template{}
+	for _, name := range pages {
+		t, err := parse(name)
+		if err != nil {
+			return nil, err
+		}
+		parsed[name] = t
+	}
+	return parsed, nil
+}
+
+func lookup(tmpl string) (*template.Template, error) {
+	if fromDisk {
+		return parse(tmpl)
+	}
+	parsed, err := cache()
+	if err != nil {
+		return nil, err
+	}
+	t, exists := parsed[tmpl]
+	if !exists {
+		return nil, &TemplateError{Template: tmpl, Op: "find", Err: ErrTemplateNotFound}
+	}
+	return t, nil
+}
+
+func execute(tmpl string, data interface{}) (*bytes.Buffer, error) {
+	t, err := lookup(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "base", data); err != nil {
+		return nil, &TemplateError{Template: tmpl, Op: "execute", Err: err}
+	}
+	return &buf, nil
+}
+
+// Render executes the page into a buffer, so nothing is written to w when it fails
+func Render(w io.Writer, tmpl string, data interface{}) error {
+	buf, err := execute(tmpl, data)
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// RenderTemplate writes the page, or the error page if it can not be rendered
+func RenderTemplate(w http.ResponseWriter, r *http.Request, tmpl string, data interface{}) error {
+	return RenderTemplateStatus(w, r, http.StatusOK, tmpl, data)
+}
+
+// RenderTemplateStatus writes the page with the status, or the error page if it can not be rendered
+func RenderTemplateStatus(w http.ResponseWriter, r *http.Request, status int, tmpl string, data interface{}) error {
+	buf, err := execute(tmpl, data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error rendering page", "method", r.Method, "path", r.URL.Path, "err", err)
+		renderErrorPage(w, r)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+	return nil
+}
+
+// renderErrorPage writes the 500 page, with the request ID to find the error in the logs
+func renderErrorPage(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"ActivePage": "500",
+		"Scheme":     ColorScheme(),
+		"RequestID":  requestid.FromRequest(r),
 	}
 
-	err = t.ExecuteTemplate(w, "base", data)
+	buf, err := execute("500.tmpl", data)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Template execution error: %v", err), http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error rendering the error page", "err", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
 	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	buf.WriteTo(w)
 }
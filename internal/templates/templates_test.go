@@ -0,0 +1,49 @@
+package templates
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	if err := Load(); err != nil {
+		t.Fatalf("Expected the embedded templates to parse, got %v", err)
+	}
+
+	parsed, _ := cache()
+	if _, exists := parsed["index.tmpl"]; !exists {
+		t.Error("Expected index.tmpl to be cached")
+	}
+	if _, exists := parsed["base.tmpl"]; exists {
+		t.Error("Expected base.tmpl to only be parsed together with the pages")
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	RenderTemplateStatus(w, r, http.StatusNotFound, "404.tmpl", map[string]interface{}{"Scheme": ColorScheme()})
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "</html>") {
+		t.Errorf("Expected the page to render, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	err := RenderTemplate(w, r, "missing.tmpl", nil)
+	if !errors.Is(err, ErrTemplateNotFound) || w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "Something Went Wrong") {
+		t.Errorf("Expected the error page for a missing template, got %d and %v", w.Code, err)
+	}
+
+	// A failure halfway through the page leaves nothing of it in the response
+	w = httptest.NewRecorder()
+	err = RenderTemplate(w, r, "about.tmpl", map[string]interface{}{"Scheme": 42})
+	var templateErr *TemplateError
+	if !errors.As(err, &templateErr) || templateErr.Op != "execute" {
+		t.Fatalf("Expected an execute error, got %v", err)
+	}
+	if w.Code != http.StatusInternalServerError || strings.Count(w.Body.String(), "<html") != 1 {
+		t.Errorf("Expected only the error page, got %d", w.Code)
+	}
+}
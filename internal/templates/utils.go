@@ -4,21 +4,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
-	"io/fs"
+	"log/slog"
 	"os"
-	"path/filepath"
 	"premodernonsdagar/internal/aggregation"
+	"premodernonsdagar/internal/assets"
+	"premodernonsdagar/internal/series"
+	"premodernonsdagar/internal/signup"
 	"slices"
 )
 
 var TemplateFuncs = map[string]interface{}{
 	"slice":    Slice,
 	"add":      func(a, b int) int { return a + b },
+	"static":   assets.Path,
 	"contains": func(slice []string, item string) bool { return slices.Contains(slice, item) },
 	"json":     func(v interface{}) template.JS {
 		jsonBytes, _ := json.Marshal(v)
 		return template.JS(jsonBytes)
 	},
+	"percent":  func(p float64) string { return fmt.Sprintf("%.0f%%", p*100) },
+	"complement": func(p float64) float64 { return 1 - p },
 	"cardtype": func(t string) string {
 		switch t {
 		case "creature":
@@ -68,57 +73,41 @@ func RenderAllTemplates() error {
 		"Scheme":              ColorScheme(),
 		"Player":              aggregation.Player{},
 		"Seasons":             []aggregation.LeaderboardSeasonEntry{},
+		"Standings":           aggregation.SeasonStandings{},
+		"Achievements":        []aggregation.AchievementOverview{},
+		"Signups":             []signup.Signup{},
+		"NextEvent":           series.Occurrence{},
 	}
 
 	htmlOutputDir := "pages/html"
 	if err := os.MkdirAll(htmlOutputDir, 0755); err != nil {
-		fmt.Printf("Failed to create output directory: %v\n", err)
+		slog.Error("Failed to create output directory", "err", err)
 		return err
 	}
 
-	templateDir := "templates"
-	templateFiles := []string{}
-	fs.WalkDir(os.DirFS(templateDir), ".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() && filepath.Ext(path) == ".tmpl" {
-			templateFiles = append(templateFiles, path)
-		}
-		return nil
-	})
+	templateFiles, err := pageTemplates()
+	if err != nil {
+		return err
+	}
 
 	for _, tmpl := range templateFiles {
 		outputFile := fmt.Sprintf("%s/%s.html", htmlOutputDir, tmpl[:len(tmpl)-5])
 
 		if err := renderTemplateToFile(tmpl, mockData, outputFile); err != nil {
-			fmt.Printf("Failed to render template %s: %v\n", tmpl, err)
+			slog.Error("Failed to render template", "template", tmpl, "err", err)
 		}
 	}
 	return nil
 }
 
 func renderTemplateToFile(tmpl string, data interface{}, outputPath string) error {
-	funcMap := template.FuncMap(TemplateFuncs)
-	t, err := template.New("base.tmpl").Funcs(funcMap).ParseFiles(
-		filepath.Join("templates", "base.tmpl"),
-		filepath.Join("templates", tmpl),
-	)
-	if err != nil {
-		return fmt.Errorf("error parsing templates: %w", err)
-	}
-
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return fmt.Errorf("error creating file: %w", err)
 	}
 	defer file.Close()
 
-	if err := t.ExecuteTemplate(file, "base", data); err != nil {
-		return fmt.Errorf("template execution error: %w", err)
-	}
-
-	return nil
+	return Render(file, tmpl, data)
 }
 
 func Rules() []string {
@@ -236,3 +236,11 @@ func Skip(r, rd, sigma float64) float64 {
 	_, phi = unscale(mu, phi)
 	return phi
 }
+
+// ExpectedScore gives the expected chance that the first player wins against the second player.
+// Both rating deviations are combined, so an uncertain rating on either side pulls the result towards 0.5.
+func ExpectedScore(r, rd, ropp, rdopp float64) float64 {
+	mu, phi := scale(r, rd)
+	muj, phij := scale(ropp, rdopp)
+	return e(mu, muj, math.Sqrt(phi*phi+phij*phij))
+}
@@ -153,6 +153,26 @@ func TestUnscale(t *testing.T) {
 	}
 }
 
+func TestExpectedScore(t *testing.T) {
+	if math.Abs(ExpectedScore(1500, 200, 1500, 200)-0.5) > epsilon {
+		t.Errorf("ExpectedScore for equal ratings")
+	}
+
+	stronger := ExpectedScore(1700, 50, 1400, 50)
+	if stronger <= 0.5 {
+		t.Errorf("ExpectedScore for stronger player = %v, want > 0.5", stronger)
+	}
+
+	if math.Abs(stronger+ExpectedScore(1400, 50, 1700, 50)-1) > epsilon {
+		t.Errorf("ExpectedScore is not symmetric")
+	}
+
+	uncertain := ExpectedScore(1700, 350, 1400, 350)
+	if uncertain >= stronger {
+		t.Errorf("ExpectedScore with high deviation = %v, want < %v", uncertain, stronger)
+	}
+}
+
 func BenchmarkRate(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -32,7 +32,7 @@ func TestDistance(t *testing.T) {
 		if result != distanceTest.wanted {
 			output := fmt.Sprintf("%v \t distance of %v and %v should be %v but was %v.",
 				index, distanceTest.first, distanceTest.second, distanceTest.wanted, result)
-			t.Errorf(output)
+			t.Errorf("%s", output)
 		}
 	}
 }
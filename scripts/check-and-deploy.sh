@@ -23,8 +23,8 @@ calculate_checksum() {
     fi
 }
 
-# Path to the etags.json file
-ETAGS_FILE="scripts/etags.json"
+# The ETags of the input files when they were last downloaded, written by "inputs pull"
+ETAGS_FILE=".inputs-sync.json"
 # Path to store the hash
 HASH_FILE="scripts/.etags.hash"
 # Path to store the git reference
@@ -32,7 +32,8 @@ GIT_REF_FILE="scripts/.git.ref"
 
 # make sure we are up to date
 git pull >/dev/null 2>&1
-/home/lauri/.cargo/bin/uv run --frozen -q scripts/admin.py download >/dev/null 2>&1
+# The bucket settings are read from the file named by CONFIG_FILE, see "Configuration" in the README
+CONFIG_FILE="${CONFIG_FILE:-scripts/inputs.env}" go run ./cmd/main inputs pull >/dev/null 2>&1
 
 # Parse command line arguments
 VERBOSE=0
@@ -40,13 +41,13 @@ if [ "$1" = "-v" ] || [ "$1" = "--verbose" ]; then
     VERBOSE=1
 fi
 
-# Check if etags.json exists
+# Check if the ETags file exists
 if [ ! -f "$ETAGS_FILE" ]; then
     echo "Error: $ETAGS_FILE not found"
     exit 1
 fi
 
-# Calculate current hash of etags.json
+# Calculate current hash of the ETags file
 CURRENT_HASH=$(calculate_checksum "$ETAGS_FILE")
 if [ $? -ne 0 ]; then
     exit 1